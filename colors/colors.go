@@ -6,148 +6,153 @@ The alpha component may be a string composed by "0x" followed by an hexadecimal
 a decimal number between 0.0 and 1.0, which represents the opacity value
 (‘0x00’ or ‘0.0’ means completely transparent, ‘0xff’ or ‘1.0’ completely opaque).
 If the alpha component is not specified then ‘0xff’ is assumed.
+
+Use Valid or Parse to check a color before submitting a job, and Format to convert any color.Color.
 */
 package colors
 
+// Color is a color accepted by the MediaMachine API, see the package documentation for the accepted formats.
+type Color = string
+
 const (
-	Aliceblue            = "aliceblue"
-	Antiquewhite         = "antiquewhite"
-	Aqua                 = "aqua"
-	Aquamarine           = "aquamarine"
-	Azure                = "azure"
-	Beige                = "beige"
-	Bisque               = "bisque"
-	Black                = "black"
-	Blanchedalmond       = "blanchedalmond"
-	Blue                 = "blue"
-	Blueviolet           = "blueviolet"
-	Brown                = "brown"
-	Burlywood            = "burlywood"
-	Cadetblue            = "cadetblue"
-	Chartreuse           = "chartreuse"
-	Chocolate            = "chocolate"
-	Coral                = "coral"
-	Cornflowerblue       = "cornflowerblue"
-	Cornsilk             = "cornsilk"
-	Crimson              = "crimson"
-	Cyan                 = "cyan"
-	Darkblue             = "darkblue"
-	Darkcyan             = "darkcyan"
-	Darkgoldenrod        = "darkgoldenrod"
-	Darkgray             = "darkgray"
-	Darkgreen            = "darkgreen"
-	Darkkhaki            = "darkkhaki"
-	Darkmagenta          = "darkmagenta"
-	Darkolivegreen       = "darkolivegreen"
-	Darkorange           = "darkorange"
-	Darkorchid           = "darkorchid"
-	Darkred              = "darkred"
-	Darksalmon           = "darksalmon"
-	Darkseagreen         = "darkseagreen"
-	Darkslateblue        = "darkslateblue"
-	Darkslategray        = "darkslategray"
-	Darkturquoise        = "darkturquoise"
-	Darkviolet           = "darkviolet"
-	Deeppink             = "deeppink"
-	Deepskyblue          = "deepskyblue"
-	Dimgray              = "dimgray"
-	Dodgerblue           = "dodgerblue"
-	Firebrick            = "firebrick"
-	Floralwhite          = "floralwhite"
-	Forestgreen          = "forestgreen"
-	Fuchsia              = "fuchsia"
-	Gainsboro            = "gainsboro"
-	Ghostwhite           = "ghostwhite"
-	Gold                 = "gold"
-	Goldenrod            = "goldenrod"
-	Gray                 = "gray"
-	Green                = "green"
-	Greenyellow          = "greenyellow"
-	Honeydew             = "honeydew"
-	Hotpink              = "hotpink"
-	Indianred            = "indianred"
-	Indigo               = "indigo"
-	Ivory                = "ivory"
-	Khaki                = "khaki"
-	Lavender             = "lavender"
-	Lavenderblush        = "lavenderblush"
-	Lawngreen            = "lawngreen"
-	Lemonchiffon         = "lemonchiffon"
-	Lightblue            = "lightblue"
-	Lightcoral           = "lightcoral"
-	Lightcyan            = "lightcyan"
-	Lightgoldenrodyellow = "lightgoldenrodyellow"
-	Lightgreen           = "lightgreen"
-	Lightgrey            = "lightgrey"
-	Lightpink            = "lightpink"
-	Lightsalmon          = "lightsalmon"
-	Lightseagreen        = "lightseagreen"
-	Lightskyblue         = "lightskyblue"
-	Lightslategray       = "lightslategray"
-	Lightsteelblue       = "lightsteelblue"
-	Lightyellow          = "lightyellow"
-	Lime                 = "lime"
-	Limegreen            = "limegreen"
-	Linen                = "linen"
-	Magenta              = "magenta"
-	Maroon               = "maroon"
-	Mediumaquamarine     = "mediumaquamarine"
-	Mediumblue           = "mediumblue"
-	Mediumorchid         = "mediumorchid"
-	Mediumpurple         = "mediumpurple"
-	Mediumseagreen       = "mediumseagreen"
-	Mediumslateblue      = "mediumslateblue"
-	Mediumspringgreen    = "mediumspringgreen"
-	Mediumturquoise      = "mediumturquoise"
-	Mediumvioletred      = "mediumvioletred"
-	Midnightblue         = "midnightblue"
-	Mintcream            = "mintcream"
-	Mistyrose            = "mistyrose"
-	Moccasin             = "moccasin"
-	Navajowhite          = "navajowhite"
-	Navy                 = "navy"
-	Oldlace              = "oldlace"
-	Olive                = "olive"
-	Olivedrab            = "olivedrab"
-	Orange               = "orange"
-	Orangered            = "orangered"
-	Orchid               = "orchid"
-	Palegoldenrod        = "palegoldenrod"
-	Palegreen            = "palegreen"
-	Paleturquoise        = "paleturquoise"
-	Palevioletred        = "palevioletred"
-	Papayawhip           = "papayawhip"
-	Peachpuff            = "peachpuff"
-	Peru                 = "peru"
-	Pink                 = "pink"
-	Plum                 = "plum"
-	Powderblue           = "powderblue"
-	Purple               = "purple"
-	Red                  = "red"
-	Rosybrown            = "rosybrown"
-	Royalblue            = "royalblue"
-	Saddlebrown          = "saddlebrown"
-	Salmon               = "salmon"
-	Sandybrown           = "sandybrown"
-	Seagreen             = "seagreen"
-	Seashell             = "seashell"
-	Sienna               = "sienna"
-	Silver               = "silver"
-	Skyblue              = "skyblue"
-	Slateblue            = "slateblue"
-	Slategray            = "slategray"
-	Snow                 = "snow"
-	Springgreen          = "springgreen"
-	Steelblue            = "steelblue"
-	Tan                  = "tan"
-	Teal                 = "teal"
-	Thistle              = "thistle"
-	Tomato               = "tomato"
-	Turquoise            = "turquoise"
-	Violet               = "violet"
-	Wheat                = "wheat"
-	White                = "white"
-	Whitesmoke           = "whitesmoke"
-	Yellow               = "yellow"
-	Yellowgreen          = "yellowgreen"
+	Aliceblue            Color = "aliceblue"
+	Antiquewhite         Color = "antiquewhite"
+	Aqua                 Color = "aqua"
+	Aquamarine           Color = "aquamarine"
+	Azure                Color = "azure"
+	Beige                Color = "beige"
+	Bisque               Color = "bisque"
+	Black                Color = "black"
+	Blanchedalmond       Color = "blanchedalmond"
+	Blue                 Color = "blue"
+	Blueviolet           Color = "blueviolet"
+	Brown                Color = "brown"
+	Burlywood            Color = "burlywood"
+	Cadetblue            Color = "cadetblue"
+	Chartreuse           Color = "chartreuse"
+	Chocolate            Color = "chocolate"
+	Coral                Color = "coral"
+	Cornflowerblue       Color = "cornflowerblue"
+	Cornsilk             Color = "cornsilk"
+	Crimson              Color = "crimson"
+	Cyan                 Color = "cyan"
+	Darkblue             Color = "darkblue"
+	Darkcyan             Color = "darkcyan"
+	Darkgoldenrod        Color = "darkgoldenrod"
+	Darkgray             Color = "darkgray"
+	Darkgreen            Color = "darkgreen"
+	Darkkhaki            Color = "darkkhaki"
+	Darkmagenta          Color = "darkmagenta"
+	Darkolivegreen       Color = "darkolivegreen"
+	Darkorange           Color = "darkorange"
+	Darkorchid           Color = "darkorchid"
+	Darkred              Color = "darkred"
+	Darksalmon           Color = "darksalmon"
+	Darkseagreen         Color = "darkseagreen"
+	Darkslateblue        Color = "darkslateblue"
+	Darkslategray        Color = "darkslategray"
+	Darkturquoise        Color = "darkturquoise"
+	Darkviolet           Color = "darkviolet"
+	Deeppink             Color = "deeppink"
+	Deepskyblue          Color = "deepskyblue"
+	Dimgray              Color = "dimgray"
+	Dodgerblue           Color = "dodgerblue"
+	Firebrick            Color = "firebrick"
+	Floralwhite          Color = "floralwhite"
+	Forestgreen          Color = "forestgreen"
+	Fuchsia              Color = "fuchsia"
+	Gainsboro            Color = "gainsboro"
+	Ghostwhite           Color = "ghostwhite"
+	Gold                 Color = "gold"
+	Goldenrod            Color = "goldenrod"
+	Gray                 Color = "gray"
+	Green                Color = "green"
+	Greenyellow          Color = "greenyellow"
+	Honeydew             Color = "honeydew"
+	Hotpink              Color = "hotpink"
+	Indianred            Color = "indianred"
+	Indigo               Color = "indigo"
+	Ivory                Color = "ivory"
+	Khaki                Color = "khaki"
+	Lavender             Color = "lavender"
+	Lavenderblush        Color = "lavenderblush"
+	Lawngreen            Color = "lawngreen"
+	Lemonchiffon         Color = "lemonchiffon"
+	Lightblue            Color = "lightblue"
+	Lightcoral           Color = "lightcoral"
+	Lightcyan            Color = "lightcyan"
+	Lightgoldenrodyellow Color = "lightgoldenrodyellow"
+	Lightgreen           Color = "lightgreen"
+	Lightgrey            Color = "lightgrey"
+	Lightpink            Color = "lightpink"
+	Lightsalmon          Color = "lightsalmon"
+	Lightseagreen        Color = "lightseagreen"
+	Lightskyblue         Color = "lightskyblue"
+	Lightslategray       Color = "lightslategray"
+	Lightsteelblue       Color = "lightsteelblue"
+	Lightyellow          Color = "lightyellow"
+	Lime                 Color = "lime"
+	Limegreen            Color = "limegreen"
+	Linen                Color = "linen"
+	Magenta              Color = "magenta"
+	Maroon               Color = "maroon"
+	Mediumaquamarine     Color = "mediumaquamarine"
+	Mediumblue           Color = "mediumblue"
+	Mediumorchid         Color = "mediumorchid"
+	Mediumpurple         Color = "mediumpurple"
+	Mediumseagreen       Color = "mediumseagreen"
+	Mediumslateblue      Color = "mediumslateblue"
+	Mediumspringgreen    Color = "mediumspringgreen"
+	Mediumturquoise      Color = "mediumturquoise"
+	Mediumvioletred      Color = "mediumvioletred"
+	Midnightblue         Color = "midnightblue"
+	Mintcream            Color = "mintcream"
+	Mistyrose            Color = "mistyrose"
+	Moccasin             Color = "moccasin"
+	Navajowhite          Color = "navajowhite"
+	Navy                 Color = "navy"
+	Oldlace              Color = "oldlace"
+	Olive                Color = "olive"
+	Olivedrab            Color = "olivedrab"
+	Orange               Color = "orange"
+	Orangered            Color = "orangered"
+	Orchid               Color = "orchid"
+	Palegoldenrod        Color = "palegoldenrod"
+	Palegreen            Color = "palegreen"
+	Paleturquoise        Color = "paleturquoise"
+	Palevioletred        Color = "palevioletred"
+	Papayawhip           Color = "papayawhip"
+	Peachpuff            Color = "peachpuff"
+	Peru                 Color = "peru"
+	Pink                 Color = "pink"
+	Plum                 Color = "plum"
+	Powderblue           Color = "powderblue"
+	Purple               Color = "purple"
+	Red                  Color = "red"
+	Rosybrown            Color = "rosybrown"
+	Royalblue            Color = "royalblue"
+	Saddlebrown          Color = "saddlebrown"
+	Salmon               Color = "salmon"
+	Sandybrown           Color = "sandybrown"
+	Seagreen             Color = "seagreen"
+	Seashell             Color = "seashell"
+	Sienna               Color = "sienna"
+	Silver               Color = "silver"
+	Skyblue              Color = "skyblue"
+	Slateblue            Color = "slateblue"
+	Slategray            Color = "slategray"
+	Snow                 Color = "snow"
+	Springgreen          Color = "springgreen"
+	Steelblue            Color = "steelblue"
+	Tan                  Color = "tan"
+	Teal                 Color = "teal"
+	Thistle              Color = "thistle"
+	Tomato               Color = "tomato"
+	Turquoise            Color = "turquoise"
+	Violet               Color = "violet"
+	Wheat                Color = "wheat"
+	White                Color = "white"
+	Whitesmoke           Color = "whitesmoke"
+	Yellow               Color = "yellow"
+	Yellowgreen          Color = "yellowgreen"
 )
@@ -0,0 +1,88 @@
+package colors
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// maxAverageSamples bounds the number of pixels read by Average on large images
+const maxAverageSamples = 10000
+
+// DefaultPalette is the palette used by MostReadable when no palette is given
+var DefaultPalette = []Color{White, Black}
+
+// RelativeLuminance returns the WCAG 2 relative luminance of c, from 0 for black to 1 for white.
+// See https://www.w3.org/TR/WCAG20/#relativeluminancedef
+func RelativeLuminance(c color.Color) float64 {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	linear := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(n.R) + 0.7152*linear(n.G) + 0.0722*linear(n.B)
+}
+
+// ContrastRatio returns the WCAG 2 contrast ratio between two colors, from 1 for identical colors to 21
+// for black on white. WCAG recommends a ratio of at least 4.5 for text.
+// See https://www.w3.org/TR/WCAG20/#contrast-ratiodef
+func ContrastRatio(a, b color.Color) float64 {
+	la, lb := RelativeLuminance(a), RelativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// MostReadable returns the color of the palette with the highest contrast ratio against background.
+// Invalid colors of the palette are skipped. DefaultPalette is used if palette is empty.
+func MostReadable(background color.Color, palette ...Color) Color {
+	if len(palette) == 0 {
+		palette = DefaultPalette
+	}
+	var best Color
+	bestRatio := 0.0
+	for _, c := range palette {
+		rgba, err := Parse(c)
+		if err != nil {
+			continue
+		}
+		if ratio := ContrastRatio(background, rgba); ratio > bestRatio {
+			best, bestRatio = c, ratio
+		}
+	}
+	return best
+}
+
+// Average returns the average color of an image, e.g. the region of a frame covered by a Watermark.
+// Large images are sampled on a regular grid.
+func Average(img image.Image) color.NRGBA {
+	b := img.Bounds()
+	if b.Empty() {
+		return color.NRGBA{}
+	}
+	step := int(math.Ceil(math.Sqrt(float64(b.Dx()*b.Dy()) / maxAverageSamples)))
+	if step < 1 {
+		step = 1
+	}
+
+	var r, g, bl, n float64
+	for y := b.Min.Y; y < b.Max.Y; y += step {
+		for x := b.Min.X; x < b.Max.X; x += step {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			r += float64(c.R)
+			g += float64(c.G)
+			bl += float64(c.B)
+			n++
+		}
+	}
+	return color.NRGBA{
+		R: uint8(math.Round(r / n)),
+		G: uint8(math.Round(g / n)),
+		B: uint8(math.Round(bl / n)),
+		A: 0xff,
+	}
+}
@@ -0,0 +1,35 @@
+package colors_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/stackrock/mediamachinego/colors"
+)
+
+var _ = Describe("Contrast", func() {
+	It("computes WCAG contrast ratios", func() {
+		Expect(colors.ContrastRatio(color.Black, color.White)).To(BeNumerically("~", 21, 0.001))
+		Expect(colors.ContrastRatio(color.White, color.White)).To(BeNumerically("~", 1, 0.001))
+		// #777777 on white is the classic borderline case for WCAG AA
+		Expect(colors.ContrastRatio(color.NRGBA{R: 0x77, G: 0x77, B: 0x77, A: 0xff}, color.White)).To(BeNumerically("~", 4.48, 0.01))
+	})
+
+	It("picks the most readable color of a palette", func() {
+		Expect(colors.MostReadable(color.NRGBA{R: 0x10, G: 0x10, B: 0x30, A: 0xff})).To(Equal(colors.White))
+		Expect(colors.MostReadable(color.NRGBA{R: 0xf0, G: 0xf0, B: 0xa0, A: 0xff})).To(Equal(colors.Black))
+		Expect(colors.MostReadable(color.Black, colors.Navy, colors.Yellow, "brwon")).To(Equal(colors.Yellow))
+	})
+
+	It("averages the colors of an image", func() {
+		img := image.NewRGBA(image.Rect(0, 0, 400, 400))
+		draw.Draw(img, image.Rect(0, 0, 200, 400), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(200, 0, 400, 400), image.NewUniform(color.Black), image.Point{}, draw.Src)
+		avg := colors.Average(img)
+		Expect(avg.R).To(BeNumerically("~", 0x80, 2))
+	})
+})
@@ -0,0 +1,249 @@
+package colors
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// named maps every color name supported by the MediaMachine API to its RGB value
+var named = map[Color]color.NRGBA{
+	Aliceblue:            {0xf0, 0xf8, 0xff, 0xff},
+	Antiquewhite:         {0xfa, 0xeb, 0xd7, 0xff},
+	Aqua:                 {0x00, 0xff, 0xff, 0xff},
+	Aquamarine:           {0x7f, 0xff, 0xd4, 0xff},
+	Azure:                {0xf0, 0xff, 0xff, 0xff},
+	Beige:                {0xf5, 0xf5, 0xdc, 0xff},
+	Bisque:               {0xff, 0xe4, 0xc4, 0xff},
+	Black:                {0x00, 0x00, 0x00, 0xff},
+	Blanchedalmond:       {0xff, 0xeb, 0xcd, 0xff},
+	Blue:                 {0x00, 0x00, 0xff, 0xff},
+	Blueviolet:           {0x8a, 0x2b, 0xe2, 0xff},
+	Brown:                {0xa5, 0x2a, 0x2a, 0xff},
+	Burlywood:            {0xde, 0xb8, 0x87, 0xff},
+	Cadetblue:            {0x5f, 0x9e, 0xa0, 0xff},
+	Chartreuse:           {0x7f, 0xff, 0x00, 0xff},
+	Chocolate:            {0xd2, 0x69, 0x1e, 0xff},
+	Coral:                {0xff, 0x7f, 0x50, 0xff},
+	Cornflowerblue:       {0x64, 0x95, 0xed, 0xff},
+	Cornsilk:             {0xff, 0xf8, 0xdc, 0xff},
+	Crimson:              {0xdc, 0x14, 0x3c, 0xff},
+	Cyan:                 {0x00, 0xff, 0xff, 0xff},
+	Darkblue:             {0x00, 0x00, 0x8b, 0xff},
+	Darkcyan:             {0x00, 0x8b, 0x8b, 0xff},
+	Darkgoldenrod:        {0xb8, 0x86, 0x0b, 0xff},
+	Darkgray:             {0xa9, 0xa9, 0xa9, 0xff},
+	Darkgreen:            {0x00, 0x64, 0x00, 0xff},
+	Darkkhaki:            {0xbd, 0xb7, 0x6b, 0xff},
+	Darkmagenta:          {0x8b, 0x00, 0x8b, 0xff},
+	Darkolivegreen:       {0x55, 0x6b, 0x2f, 0xff},
+	Darkorange:           {0xff, 0x8c, 0x00, 0xff},
+	Darkorchid:           {0x99, 0x32, 0xcc, 0xff},
+	Darkred:              {0x8b, 0x00, 0x00, 0xff},
+	Darksalmon:           {0xe9, 0x96, 0x7a, 0xff},
+	Darkseagreen:         {0x8f, 0xbc, 0x8f, 0xff},
+	Darkslateblue:        {0x48, 0x3d, 0x8b, 0xff},
+	Darkslategray:        {0x2f, 0x4f, 0x4f, 0xff},
+	Darkturquoise:        {0x00, 0xce, 0xd1, 0xff},
+	Darkviolet:           {0x94, 0x00, 0xd3, 0xff},
+	Deeppink:             {0xff, 0x14, 0x93, 0xff},
+	Deepskyblue:          {0x00, 0xbf, 0xff, 0xff},
+	Dimgray:              {0x69, 0x69, 0x69, 0xff},
+	Dodgerblue:           {0x1e, 0x90, 0xff, 0xff},
+	Firebrick:            {0xb2, 0x22, 0x22, 0xff},
+	Floralwhite:          {0xff, 0xfa, 0xf0, 0xff},
+	Forestgreen:          {0x22, 0x8b, 0x22, 0xff},
+	Fuchsia:              {0xff, 0x00, 0xff, 0xff},
+	Gainsboro:            {0xdc, 0xdc, 0xdc, 0xff},
+	Ghostwhite:           {0xf8, 0xf8, 0xff, 0xff},
+	Gold:                 {0xff, 0xd7, 0x00, 0xff},
+	Goldenrod:            {0xda, 0xa5, 0x20, 0xff},
+	Gray:                 {0x80, 0x80, 0x80, 0xff},
+	Green:                {0x00, 0x80, 0x00, 0xff},
+	Greenyellow:          {0xad, 0xff, 0x2f, 0xff},
+	Honeydew:             {0xf0, 0xff, 0xf0, 0xff},
+	Hotpink:              {0xff, 0x69, 0xb4, 0xff},
+	Indianred:            {0xcd, 0x5c, 0x5c, 0xff},
+	Indigo:               {0x4b, 0x00, 0x82, 0xff},
+	Ivory:                {0xff, 0xff, 0xf0, 0xff},
+	Khaki:                {0xf0, 0xe6, 0x8c, 0xff},
+	Lavender:             {0xe6, 0xe6, 0xfa, 0xff},
+	Lavenderblush:        {0xff, 0xf0, 0xf5, 0xff},
+	Lawngreen:            {0x7c, 0xfc, 0x00, 0xff},
+	Lemonchiffon:         {0xff, 0xfa, 0xcd, 0xff},
+	Lightblue:            {0xad, 0xd8, 0xe6, 0xff},
+	Lightcoral:           {0xf0, 0x80, 0x80, 0xff},
+	Lightcyan:            {0xe0, 0xff, 0xff, 0xff},
+	Lightgoldenrodyellow: {0xfa, 0xfa, 0xd2, 0xff},
+	Lightgreen:           {0x90, 0xee, 0x90, 0xff},
+	Lightgrey:            {0xd3, 0xd3, 0xd3, 0xff},
+	Lightpink:            {0xff, 0xb6, 0xc1, 0xff},
+	Lightsalmon:          {0xff, 0xa0, 0x7a, 0xff},
+	Lightseagreen:        {0x20, 0xb2, 0xaa, 0xff},
+	Lightskyblue:         {0x87, 0xce, 0xfa, 0xff},
+	Lightslategray:       {0x77, 0x88, 0x99, 0xff},
+	Lightsteelblue:       {0xb0, 0xc4, 0xde, 0xff},
+	Lightyellow:          {0xff, 0xff, 0xe0, 0xff},
+	Lime:                 {0x00, 0xff, 0x00, 0xff},
+	Limegreen:            {0x32, 0xcd, 0x32, 0xff},
+	Linen:                {0xfa, 0xf0, 0xe6, 0xff},
+	Magenta:              {0xff, 0x00, 0xff, 0xff},
+	Maroon:               {0x80, 0x00, 0x00, 0xff},
+	Mediumaquamarine:     {0x66, 0xcd, 0xaa, 0xff},
+	Mediumblue:           {0x00, 0x00, 0xcd, 0xff},
+	Mediumorchid:         {0xba, 0x55, 0xd3, 0xff},
+	Mediumpurple:         {0x93, 0x70, 0xdb, 0xff},
+	Mediumseagreen:       {0x3c, 0xb3, 0x71, 0xff},
+	Mediumslateblue:      {0x7b, 0x68, 0xee, 0xff},
+	Mediumspringgreen:    {0x00, 0xfa, 0x9a, 0xff},
+	Mediumturquoise:      {0x48, 0xd1, 0xcc, 0xff},
+	Mediumvioletred:      {0xc7, 0x15, 0x85, 0xff},
+	Midnightblue:         {0x19, 0x19, 0x70, 0xff},
+	Mintcream:            {0xf5, 0xff, 0xfa, 0xff},
+	Mistyrose:            {0xff, 0xe4, 0xe1, 0xff},
+	Moccasin:             {0xff, 0xe4, 0xb5, 0xff},
+	Navajowhite:          {0xff, 0xde, 0xad, 0xff},
+	Navy:                 {0x00, 0x00, 0x80, 0xff},
+	Oldlace:              {0xfd, 0xf5, 0xe6, 0xff},
+	Olive:                {0x80, 0x80, 0x00, 0xff},
+	Olivedrab:            {0x6b, 0x8e, 0x23, 0xff},
+	Orange:               {0xff, 0xa5, 0x00, 0xff},
+	Orangered:            {0xff, 0x45, 0x00, 0xff},
+	Orchid:               {0xda, 0x70, 0xd6, 0xff},
+	Palegoldenrod:        {0xee, 0xe8, 0xaa, 0xff},
+	Palegreen:            {0x98, 0xfb, 0x98, 0xff},
+	Paleturquoise:        {0xaf, 0xee, 0xee, 0xff},
+	Palevioletred:        {0xdb, 0x70, 0x93, 0xff},
+	Papayawhip:           {0xff, 0xef, 0xd5, 0xff},
+	Peachpuff:            {0xff, 0xda, 0xb9, 0xff},
+	Peru:                 {0xcd, 0x85, 0x3f, 0xff},
+	Pink:                 {0xff, 0xc0, 0xcb, 0xff},
+	Plum:                 {0xdd, 0xa0, 0xdd, 0xff},
+	Powderblue:           {0xb0, 0xe0, 0xe6, 0xff},
+	Purple:               {0x80, 0x00, 0x80, 0xff},
+	Red:                  {0xff, 0x00, 0x00, 0xff},
+	Rosybrown:            {0xbc, 0x8f, 0x8f, 0xff},
+	Royalblue:            {0x41, 0x69, 0xe1, 0xff},
+	Saddlebrown:          {0x8b, 0x45, 0x13, 0xff},
+	Salmon:               {0xfa, 0x80, 0x72, 0xff},
+	Sandybrown:           {0xf4, 0xa4, 0x60, 0xff},
+	Seagreen:             {0x2e, 0x8b, 0x57, 0xff},
+	Seashell:             {0xff, 0xf5, 0xee, 0xff},
+	Sienna:               {0xa0, 0x52, 0x2d, 0xff},
+	Silver:               {0xc0, 0xc0, 0xc0, 0xff},
+	Skyblue:              {0x87, 0xce, 0xeb, 0xff},
+	Slateblue:            {0x6a, 0x5a, 0xcd, 0xff},
+	Slategray:            {0x70, 0x80, 0x90, 0xff},
+	Snow:                 {0xff, 0xfa, 0xfa, 0xff},
+	Springgreen:          {0x00, 0xff, 0x7f, 0xff},
+	Steelblue:            {0x46, 0x82, 0xb4, 0xff},
+	Tan:                  {0xd2, 0xb4, 0x8c, 0xff},
+	Teal:                 {0x00, 0x80, 0x80, 0xff},
+	Thistle:              {0xd8, 0xbf, 0xd8, 0xff},
+	Tomato:               {0xff, 0x63, 0x47, 0xff},
+	Turquoise:            {0x40, 0xe0, 0xd0, 0xff},
+	Violet:               {0xee, 0x82, 0xee, 0xff},
+	Wheat:                {0xf5, 0xde, 0xb3, 0xff},
+	White:                {0xff, 0xff, 0xff, 0xff},
+	Whitesmoke:           {0xf5, 0xf5, 0xf5, 0xff},
+	Yellow:               {0xff, 0xff, 0x00, 0xff},
+	Yellowgreen:          {0x9a, 0xcd, 0x32, 0xff},
+}
+
+/*
+Parse converts a color accepted by the MediaMachine API to its RGBA value.
+
+s can be a color name from this package or a [0x|#]RRGGBB[AA] sequence, optionally followed by @ and
+an alpha component which overrides the alpha of the color, e.g. "white@0.5" or "#ff0000@0x80".
+*/
+func Parse(s Color) (color.NRGBA, error) {
+	base, alpha := s, ""
+	if i := strings.IndexByte(s, '@'); i >= 0 {
+		base, alpha = s[:i], s[i+1:]
+	}
+
+	c, ok := named[strings.ToLower(base)]
+	if !ok {
+		var err error
+		if c, err = parseHex(base); err != nil {
+			return color.NRGBA{}, fmt.Errorf("invalid color '%s': %s", s, err)
+		}
+	}
+
+	if alpha != "" {
+		a, err := parseAlpha(alpha)
+		if err != nil {
+			return color.NRGBA{}, fmt.Errorf("invalid color '%s': %s", s, err)
+		}
+		c.A = a
+	}
+	return c, nil
+}
+
+// Valid reports whether s is a color accepted by the MediaMachine API. See Parse for the accepted formats.
+func Valid(s Color) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// Lookup returns the RGB value of a color name from this package. Names are case insensitive.
+func Lookup(name Color) (color.NRGBA, bool) {
+	c, ok := named[strings.ToLower(name)]
+	return c, ok
+}
+
+// Names returns all the color names from this package, sorted alphabetically.
+func Names() []Color {
+	names := make([]Color, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// Format converts any color to a #RRGGBB sequence, or #RRGGBBAA if it is not fully opaque.
+func Format(c color.Color) Color {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if n.A == 0xff {
+		return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", n.R, n.G, n.B, n.A)
+}
+
+func parseHex(s string) (color.NRGBA, error) {
+	switch {
+	case strings.HasPrefix(s, "0x"), strings.HasPrefix(s, "0X"):
+		s = s[2:]
+	case strings.HasPrefix(s, "#"):
+		s = s[1:]
+	}
+	if len(s) != 6 && len(s) != 8 {
+		return color.NRGBA{}, fmt.Errorf("not a color name or a RRGGBB[AA] sequence")
+	}
+	if len(s) == 6 {
+		s += "ff"
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("not a color name or a RRGGBB[AA] sequence")
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+func parseAlpha(s string) (uint8, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		v, err := strconv.ParseUint(s[2:], 16, 8)
+		if err != nil {
+			return 0, fmt.Errorf("alpha must be between 0x00 and 0xff")
+		}
+		return uint8(v), nil
+	}
+	a, err := strconv.ParseFloat(s, 64)
+	if err != nil || a < 0 || a > 1 {
+		return 0, fmt.Errorf("alpha must be between 0.0 and 1.0")
+	}
+	return uint8(a*255 + 0.5), nil
+}
@@ -0,0 +1,61 @@
+package colors_test
+
+import (
+	"image/color"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/stackrock/mediamachinego/colors"
+)
+
+var _ = Describe("Parse", func() {
+	It("parses color names", func() {
+		c, err := colors.Parse("Brown")
+		Expect(err).To(BeNil())
+		Expect(c).To(Equal(color.NRGBA{R: 0xa5, G: 0x2a, B: 0x2a, A: 0xff}))
+	})
+
+	It("parses hex sequences with an alpha component", func() {
+		c, err := colors.Parse("0x11223344")
+		Expect(err).To(BeNil())
+		Expect(c).To(Equal(color.NRGBA{R: 0x11, G: 0x22, B: 0x33, A: 0x44}))
+
+		c, err = colors.Parse("#112233@0.5")
+		Expect(err).To(BeNil())
+		Expect(c).To(Equal(color.NRGBA{R: 0x11, G: 0x22, B: 0x33, A: 0x80}))
+
+		c, err = colors.Parse("white@0x10")
+		Expect(err).To(BeNil())
+		Expect(c).To(Equal(color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0x10}))
+	})
+
+	It("rejects typos and malformed values", func() {
+		for _, s := range []string{"brwon", "#12345", "#1122334", "white@1.5", "white@0x100", ""} {
+			Expect(colors.Valid(s)).To(BeFalse(), s)
+		}
+	})
+
+	It("keeps color names usable as plain strings", func() {
+		var name string = colors.Red
+		Expect(colors.Valid(name)).To(BeTrue())
+		Expect(colors.MostReadable(color.Black, name, "not-a-color")).To(Equal(name))
+	})
+
+	It("knows the RGB value of every named color", func() {
+		Expect(colors.Names()).To(HaveLen(140))
+		for _, name := range colors.Names() {
+			_, ok := colors.Lookup(name)
+			Expect(ok).To(BeTrue(), name)
+		}
+	})
+
+	It("formats colors as hex sequences that parse back", func() {
+		Expect(colors.Format(color.White)).To(Equal("#ffffff"))
+		formatted := colors.Format(color.NRGBA{R: 1, G: 2, B: 3, A: 4})
+		Expect(formatted).To(Equal("#01020304"))
+		c, err := colors.Parse(formatted)
+		Expect(err).To(BeNil())
+		Expect(c).To(Equal(color.NRGBA{R: 1, G: 2, B: 3, A: 4}))
+	})
+})
@@ -43,6 +43,19 @@ func main() {
 		Container: mediamachine.ContainerWebm,
 		Encoder:   mediamachine.EncoderVp8,
 
+		// Brand the video with a logo during the first 10 seconds, fading it out over the last second.
+		Watermark: mediamachine.WatermarkImageNamed{
+			ImageName: "company-logo",
+			Height:    50,
+			Width:     50,
+			Opacity:   0.7,
+			Position:  mediamachine.PositionTopRight,
+			Timing: mediamachine.WatermarkTiming{
+				End:     time.Second * 10,
+				FadeOut: time.Second,
+			},
+		},
+
 		// You can opt to get notified via webhooks here or periodically check job status depending on your preferred setup
 		SuccessURL: "https://example.com/mediamachine/jobdone",
 		FailureURL: "https://example.com/mediamachine/jobfailed",
@@ -0,0 +1,136 @@
+package mediamachine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+const (
+	// MaxAudioBitrateKBPS is the largest BitrateKBPS of an audio output
+	MaxAudioBitrateKBPS = 512
+	// MaxAudioChannels is the largest number of Channels of an audio output
+	MaxAudioChannels = 8
+)
+
+const (
+	// Bitrate320Kbps is the configuration for a `320 kbps` audio bitrate.
+	Bitrate320Kbps TranscodeBitrate = "320"
+	// Bitrate192Kbps is the configuration for a `192 kbps` audio bitrate.
+	Bitrate192Kbps TranscodeBitrate = "192"
+	// Bitrate128Kbps is the configuration for a `128 kbps` audio bitrate.
+	Bitrate128Kbps TranscodeBitrate = "128"
+	// Bitrate64Kbps is the configuration for a `64 kbps` audio bitrate.
+	Bitrate64Kbps TranscodeBitrate = "64"
+)
+
+// audioSampleRates lists the supported SampleRate values of an audio output
+var audioSampleRates = map[uint]bool{
+	8000: true, 11025: true, 16000: true, 22050: true, 24000: true, 32000: true, 44100: true, 48000: true, 96000: true,
+}
+
+/*
+ExtractAudioConfig configures the request for extracting the audio track of the input video.
+
+Container must be an audio-only container, see TranscodeContainer.IsAudioOnly. Encoder defaults to the first
+encoder supported by Container.
+*/
+type ExtractAudioConfig struct {
+	Container   TranscodeContainer // required, one of ContainerM4A, ContainerMP3, ContainerOgg or ContainerWAV
+	Encoder     TranscodeEncoder   // Optional - defaults to the first of Container.Encoders()
+	BitrateKBPS TranscodeBitrate   // Optional - e.g. Bitrate128Kbps, chosen by the encoder by default, not applicable to EncoderPCM
+	SampleRate  uint               // Optional - in Hz, e.g. 44100, by default the output keeps the input sample rate
+	Channels    uint               // Optional - e.g. 1 for mono, by default the output keeps the input channels
+	Track       uint               // Optional - index of the audio track of the input, defaults to the first one
+	Normalize   bool               // Optional - normalize the loudness of the output, e.g. for podcasts
+
+	// Structured as {http|https|s3|azure|gcp}://{bucket-name}/{prefix-if-any}/{object-name}
+	// Examples: s3://bucket/prefix/input.mp4, https://example.com/files/input.mp4
+	InputURL  string
+	OutputURL string
+
+	// Provide credentials to S3/Azure/GCP for input/output locations
+	// Can be nil if using http(s) input/output urls - make sure url endpoints are accessible
+	// Note: You can use a different set of creds for input and output if you want to upload to a totally different
+	// account for example or to a different bucket if you generate keys specific to bucket etc. or reuse the same
+	// Creds object. See examples folder for usage.
+	InputCreds  Creds
+	OutputCreds Creds
+
+	SuccessURL string // Optional - Expect a POST call when job is successfully finished
+	FailureURL string // Optional - Expect a POST call with failure details
+}
+
+/*
+ExtractAudio enqueues a request to the MediaMachine backend to asynchronously extract the audio track of the input video.
+
+The output audio file is uploaded to the location specified in the ExtractAudioConfig.
+Errors if the input configuration is invalid.
+*/
+func (m MediaMachine) ExtractAudio(cfg ExtractAudioConfig) (Job, error) {
+	if err := validateInputOutput(cfg.InputURL, cfg.OutputURL, cfg.InputCreds, cfg.OutputCreds); err != nil {
+		return Job{}, err
+	}
+	if !cfg.Container.IsAudioOnly() {
+		return Job{}, fmt.Errorf("audio Container must be an audio-only container, got '%s'", cfg.Container)
+	}
+	if cfg.Encoder == "" {
+		cfg.Encoder = cfg.Container.Encoders()[0]
+	}
+	if err := validateCodecs(cfg.Container, cfg.Encoder); err != nil {
+		return Job{}, err
+	}
+	bitrate, err := parseBitrate(cfg.BitrateKBPS)
+	if err != nil {
+		return Job{}, err
+	}
+	if err := validateAudioOutput(cfg.Encoder, bitrate, cfg.SampleRate, cfg.Channels); err != nil {
+		return Job{}, err
+	}
+
+	ar := struct {
+		APIKey string
+		ExtractAudioConfig
+	}{
+		APIKey:             m.APIKey,
+		ExtractAudioConfig: cfg,
+	}
+
+	body, err := json.Marshal(ar)
+	if err != nil {
+		return Job{}, err
+	}
+	return m.submit("/audio/extract", bytes.NewBuffer(body))
+}
+
+// parseBitrate reads the number of kbps of a TranscodeBitrate, 0 if it is not set
+func parseBitrate(bitrate TranscodeBitrate) (uint, error) {
+	if bitrate == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseUint(bitrate, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid BitrateKBPS: '%s'", bitrate)
+	}
+	return uint(v), nil
+}
+
+func validateAudioOutput(encoder TranscodeEncoder, bitrate, sampleRate, channels uint) error {
+	if bitrate > MaxAudioBitrateKBPS {
+		return fmt.Errorf("audio BitrateKBPS cannot be greater than %d", MaxAudioBitrateKBPS)
+	}
+	if bitrate != 0 && encoder == EncoderPCM {
+		return fmt.Errorf("audio BitrateKBPS is not applicable to encoder '%s'", EncoderPCM)
+	}
+	if sampleRate != 0 && !audioSampleRates[sampleRate] {
+		return fmt.Errorf("unsupported audio SampleRate: %d", sampleRate)
+	}
+	if channels > MaxAudioChannels {
+		return fmt.Errorf("audio Channels cannot be greater than %d", MaxAudioChannels)
+	}
+	if channels > 2 && encoder == EncoderMP3 {
+		return fmt.Errorf("encoder '%s' supports at most 2 audio Channels", EncoderMP3)
+	}
+	return nil
+}
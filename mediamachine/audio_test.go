@@ -0,0 +1,85 @@
+package mediamachine_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/stackrock/mediamachinego/mediamachine"
+)
+
+var _ = Describe("ExtractAudio", func() {
+	mm := mediamachine.MediaMachine{}
+	cfg := mediamachine.ExtractAudioConfig{
+		Container: mediamachine.ContainerMP3,
+		InputURL:  "https://example.com/input.mp4",
+		OutputURL: "https://example.com/audio.mp3",
+	}
+
+	It("requires an audio-only container", func() {
+		c := cfg
+		c.Container = mediamachine.ContainerMP4
+		_, err := mm.ExtractAudio(c)
+		Expect(err).To(MatchError(ContainSubstring("audio-only")))
+	})
+
+	It("sends the bitrate like TranscodeConfig", func() {
+		api := &fakeAPI{response: `{"id":"job-1"}`}
+		restore := mediamachine.UseTransport(api)
+		defer restore()
+
+		c := cfg
+		c.BitrateKBPS = mediamachine.Bitrate192Kbps
+		_, err := mm.ExtractAudio(c)
+		Expect(err).To(BeNil())
+		Expect(api.body).To(HaveKeyWithValue("BitrateKBPS", "192"))
+	})
+
+	It("rejects invalid audio settings", func() {
+		for _, c := range []mediamachine.ExtractAudioConfig{
+			{Container: mediamachine.ContainerM4A, Encoder: mediamachine.EncoderMP3},
+			{Container: mediamachine.ContainerWAV, BitrateKBPS: mediamachine.Bitrate128Kbps},
+			{Container: mediamachine.ContainerMP3, BitrateKBPS: mediamachine.Bitrate2Mbps},
+			{Container: mediamachine.ContainerMP3, BitrateKBPS: "high"},
+			{Container: mediamachine.ContainerMP3, Channels: 6},
+			{Container: mediamachine.ContainerOgg, SampleRate: 12345},
+		} {
+			c.InputURL, c.OutputURL = cfg.InputURL, cfg.OutputURL
+			_, err := mm.ExtractAudio(c)
+			Expect(err).NotTo(BeNil(), "%+v", c)
+		}
+	})
+})
+
+var _ = Describe("Waveform", func() {
+	mm := mediamachine.MediaMachine{}
+
+	It("rejects settings of the other format", func() {
+		_, err := mm.Waveform(mediamachine.WaveformConfig{
+			InputURL:  "https://example.com/input.mp3",
+			OutputURL: "https://example.com/waveform.json",
+			Width:     1800,
+		})
+		Expect(err).To(MatchError(ContainSubstring("only applicable")))
+
+		_, err = mm.Waveform(mediamachine.WaveformConfig{
+			Format:    mediamachine.WaveformFormatPNG,
+			InputURL:  "https://example.com/input.mp3",
+			OutputURL: "https://example.com/waveform.png",
+		})
+		Expect(err).To(MatchError(ContainSubstring("Width and Height")))
+	})
+
+	It("parses JSON waveforms", func() {
+		w, err := mediamachine.ParseWaveform(strings.NewReader(`{"version":2,"channels":2,"sample_rate":44100,` +
+			`"samples_per_pixel":4410,"bits":8,"length":2,"data":[-64,64,-128,127,-32,32,0,0]}`))
+		Expect(err).To(BeNil())
+		mins, maxs := w.Peaks(1)
+		Expect(mins).To(Equal([]float64{-1, 0}))
+		Expect(maxs).To(Equal([]float64{127.0 / 128, 0}))
+
+		_, err = mediamachine.ParseWaveform(strings.NewReader(`{"version":2,"channels":2,"bits":8,"length":2,"data":[0,0]}`))
+		Expect(err).NotTo(BeNil())
+	})
+})
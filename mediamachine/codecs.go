@@ -0,0 +1,74 @@
+package mediamachine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// containerEncoders lists the encoders that can be muxed into each container
+var containerEncoders = map[TranscodeContainer][]TranscodeEncoder{
+	ContainerMP4:    {EncoderH264, EncoderH265, EncoderAV1},
+	ContainerWebm:   {EncoderVp8, EncoderVp9, EncoderAV1},
+	ContainerMKV:    {EncoderH264, EncoderH265, EncoderVp8, EncoderVp9, EncoderAV1, EncoderProRes},
+	ContainerMOV:    {EncoderH264, EncoderH265, EncoderProRes},
+	ContainerMPEGTS: {EncoderH264, EncoderH265},
+	ContainerM4A:    {EncoderAAC},
+	ContainerMP3:    {EncoderMP3},
+	ContainerOgg:    {EncoderVorbis, EncoderOpus},
+	ContainerWAV:    {EncoderPCM},
+}
+
+// IsAudio reports whether the encoder produces an audio-only output.
+func (e TranscodeEncoder) IsAudio() bool {
+	switch e {
+	case EncoderAAC, EncoderMP3, EncoderVorbis, EncoderOpus, EncoderPCM:
+		return true
+	}
+	return false
+}
+
+// IsAudioOnly reports whether the container holds audio only.
+func (c TranscodeContainer) IsAudioOnly() bool {
+	switch c {
+	case ContainerM4A, ContainerMP3, ContainerOgg, ContainerWAV:
+		return true
+	}
+	return false
+}
+
+// Encoders returns the encoders that are compatible with the container.
+// Returns nil for an unsupported container.
+func (c TranscodeContainer) Encoders() []TranscodeEncoder {
+	encoders := containerEncoders[c]
+	if encoders == nil {
+		return nil
+	}
+	return append([]TranscodeEncoder(nil), encoders...)
+}
+
+// Supports reports whether the encoder can be used with the container.
+func (c TranscodeContainer) Supports(e TranscodeEncoder) bool {
+	for _, supported := range containerEncoders[c] {
+		if supported == e {
+			return true
+		}
+	}
+	return false
+}
+
+func validateCodecs(container TranscodeContainer, encoder TranscodeEncoder) error {
+	encoders, ok := containerEncoders[container]
+	if !ok {
+		return fmt.Errorf("unsupported Container: '%s'", container)
+	}
+	if container.Supports(encoder) {
+		return nil
+	}
+
+	names := make([]string, len(encoders))
+	for i, e := range encoders {
+		names[i] = string(e)
+	}
+	return fmt.Errorf("encoder '%s' cannot be used with container '%s', supported encoders: %s",
+		encoder, container, strings.Join(names, ", "))
+}
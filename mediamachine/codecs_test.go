@@ -0,0 +1,63 @@
+package mediamachine_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/stackrock/mediamachinego/mediamachine"
+)
+
+var _ = Describe("Codecs", func() {
+	mm := mediamachine.MediaMachine{APIKey: "key"}
+	api := &fakeAPI{response: `{"id":"job-1"}`}
+	var restore func()
+	BeforeEach(func() { restore = mediamachine.UseTransport(api) })
+	AfterEach(func() { restore() })
+
+	transcode := func(container mediamachine.TranscodeContainer, encoder mediamachine.TranscodeEncoder,
+		bitrate mediamachine.TranscodeBitrate) error {
+		_, err := mm.Transcode(mediamachine.TranscodeConfig{
+			Container:   container,
+			Encoder:     encoder,
+			BitrateKBPS: bitrate,
+			InputURL:    "https://example.com/input.mp4",
+			OutputURL:   "https://example.com/output",
+		})
+		return err
+	}
+
+	It("lists the encoders supported by each container", func() {
+		Expect(mediamachine.ContainerWebm.Encoders()).To(ConsistOf(
+			mediamachine.EncoderVp8, mediamachine.EncoderVp9, mediamachine.EncoderAV1))
+		Expect(mediamachine.ContainerMOV.Supports(mediamachine.EncoderProRes)).To(BeTrue())
+		Expect(mediamachine.ContainerMP4.Supports(mediamachine.EncoderVp9)).To(BeFalse())
+		Expect(mediamachine.TranscodeContainer("avi").Encoders()).To(BeNil())
+
+		Expect(mediamachine.ContainerOgg.IsAudioOnly()).To(BeTrue())
+		Expect(mediamachine.ContainerMKV.IsAudioOnly()).To(BeFalse())
+		Expect(mediamachine.EncoderOpus.IsAudio()).To(BeTrue())
+		Expect(mediamachine.EncoderAV1.IsAudio()).To(BeFalse())
+	})
+
+	It("rejects encoders the container cannot hold", func() {
+		err := transcode(mediamachine.ContainerMP4, mediamachine.EncoderVp9, mediamachine.Bitrate2Mbps)
+		Expect(err).To(MatchError(ContainSubstring("supported encoders: h264, h265, av1")))
+
+		err = transcode("avi", mediamachine.EncoderH264, mediamachine.Bitrate2Mbps)
+		Expect(err).To(MatchError(ContainSubstring("unsupported Container")))
+	})
+
+	It("checks the bitrate of audio-only outputs", func() {
+		Expect(transcode(mediamachine.ContainerMP3, mediamachine.EncoderMP3, mediamachine.Bitrate128Kbps)).To(BeNil())
+		Expect(api.body).To(HaveKeyWithValue("BitrateKBPS", "128"))
+
+		err := transcode(mediamachine.ContainerMP3, mediamachine.EncoderMP3, mediamachine.Bitrate4Mbps)
+		Expect(err).To(MatchError(ContainSubstring("cannot be greater than 512")))
+
+		err = transcode(mediamachine.ContainerWAV, mediamachine.EncoderPCM, mediamachine.Bitrate128Kbps)
+		Expect(err).To(MatchError(ContainSubstring("not applicable")))
+
+		err = transcode(mediamachine.ContainerM4A, mediamachine.EncoderAAC, "fast")
+		Expect(err).To(MatchError(ContainSubstring("invalid BitrateKBPS")))
+	})
+})
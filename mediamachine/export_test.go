@@ -0,0 +1,10 @@
+package mediamachine
+
+import "net/http"
+
+// UseTransport sends the requests of the package through rt, until restore is called.
+func UseTransport(rt http.RoundTripper) (restore func()) {
+	prev := httpClient.Transport
+	httpClient.Transport = rt
+	return func() { httpClient.Transport = prev }
+}
@@ -0,0 +1,185 @@
+package mediamachine
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/stackrock/mediamachinego/colors"
+)
+
+// FitMode controls how the input picture is resized when both Width and Height of the output are set.
+// If no FitMode is given, the picture is stretched (FitStretch) to the requested size.
+type FitMode = string
+
+// CropMode selects which part of the picture is kept when the output is cropped with FitCover.
+type CropMode = string
+
+// Rotation is the rotation applied to the input picture before it is resized.
+type Rotation = string
+
+const (
+	// FitContain scales the picture to fit within Width x Height, the remaining area is padded with PadColor
+	FitContain FitMode = "contain"
+	// FitCover scales the picture to cover Width x Height, the overflow is cropped according to CropMode
+	FitCover FitMode = "cover"
+	// FitStretch scales the picture to exactly Width x Height, ignoring the input aspect ratio
+	FitStretch FitMode = "stretch"
+
+	// CropCenter keeps the center of the picture
+	CropCenter CropMode = "center"
+	// CropSmart keeps the most interesting region of the picture, as detected by MediaMachine
+	CropSmart CropMode = "smart"
+
+	// RotateAuto applies the rotation found in the input metadata
+	RotateAuto Rotation = "auto"
+	// Rotate90 rotates the picture 90 degrees clockwise
+	Rotate90 Rotation = "90"
+	// Rotate180 rotates the picture 180 degrees
+	Rotate180 Rotation = "180"
+	// Rotate270 rotates the picture 270 degrees clockwise
+	Rotate270 Rotation = "270"
+)
+
+// Crop is a rectangle, in input pixels, that is cut out of the input picture before any resizing happens.
+type Crop struct {
+	X      uint // Left edge of the rectangle
+	Y      uint // Top edge of the rectangle
+	Width  uint
+	Height uint
+}
+
+// Size holds the dimensions of a picture in pixels.
+type Size struct {
+	Width  uint
+	Height uint
+}
+
+/*
+Layout describes the output frame produced for a given input size.
+
+Content is the area of the frame covered by the input picture. It is smaller than the frame when
+FitContain adds letterbox padding, and equal to the frame otherwise.
+*/
+type Layout struct {
+	Size
+	Content image.Rectangle
+}
+
+/*
+Picture controls how the input picture is fitted into the output frame. It is embedded in TranscodeConfig,
+ThumbnailConfig and SummaryConfig, and its fields are sent along with the other fields of the config.
+
+When both Width and Height of the config are set, Fit controls how the input picture is resized into the output
+frame. The OutputLayout method of the configs computes the resulting output dimensions locally. When Rotate is
+RotateAuto, the size given to OutputLayout should be the displayed size of the input video, i.e. with the
+rotation from its metadata already applied.
+*/
+type Picture struct {
+	Fit      FitMode      // Optional - only applicable when both Width and Height are set, defaults to FitStretch
+	PadColor colors.Color // Optional - letterbox color when Fit is FitContain, defaults to black
+	CropMode CropMode     // Optional - only applicable when Fit is FitCover, defaults to CropCenter
+	Crop     *Crop        // Optional - cut a rectangle out of the input before resizing
+	Rotate   Rotation     // Optional - rotate the input before resizing. See Rotation
+}
+
+// geometry holds the sizing fields shared by the operation configs
+type geometry struct {
+	width  uint
+	height uint
+	Picture
+}
+
+func (g geometry) validate() error {
+	switch g.Fit {
+	case "", FitContain, FitCover, FitStretch:
+	default:
+		return fmt.Errorf("unsupported Fit mode: '%s'", g.Fit)
+	}
+	if g.Fit != "" && (g.width == 0 || g.height == 0) {
+		return fmt.Errorf("fit mode '%s' requires both Width and Height to be set", g.Fit)
+	}
+	if g.PadColor != "" && g.Fit != FitContain {
+		return fmt.Errorf("PadColor is only applicable when Fit is '%s'", FitContain)
+	}
+	if g.PadColor != "" && !colors.Valid(g.PadColor) {
+		return fmt.Errorf("invalid PadColor: '%s'", g.PadColor)
+	}
+
+	switch g.CropMode {
+	case "", CropCenter, CropSmart:
+	default:
+		return fmt.Errorf("unsupported CropMode: '%s'", g.CropMode)
+	}
+	if g.CropMode != "" && g.Fit != FitCover {
+		return fmt.Errorf("CropMode is only applicable when Fit is '%s'", FitCover)
+	}
+
+	if g.Crop != nil && (g.Crop.Width == 0 || g.Crop.Height == 0) {
+		return fmt.Errorf("crop rectangle must have a non-zero Width and Height")
+	}
+
+	switch g.Rotate {
+	case "", RotateAuto, Rotate90, Rotate180, Rotate270:
+	default:
+		return fmt.Errorf("unsupported Rotate value: '%s'", g.Rotate)
+	}
+	return nil
+}
+
+// layout computes the output frame for an input picture of the given size.
+// For RotateAuto, src is expected to already be the displayed (rotated) size of the input.
+func (g geometry) layout(src Size) (Layout, error) {
+	if err := g.validate(); err != nil {
+		return Layout{}, err
+	}
+	if src.Width == 0 || src.Height == 0 {
+		return Layout{}, fmt.Errorf("input size must have a non-zero Width and Height")
+	}
+
+	if g.Crop != nil {
+		if g.Crop.Width > src.Width || g.Crop.X > src.Width-g.Crop.Width ||
+			g.Crop.Height > src.Height || g.Crop.Y > src.Height-g.Crop.Height {
+			return Layout{}, fmt.Errorf("crop rectangle %dx%d+%d+%d is outside of the %dx%d input",
+				g.Crop.Width, g.Crop.Height, g.Crop.X, g.Crop.Y, src.Width, src.Height)
+		}
+		src = Size{Width: g.Crop.Width, Height: g.Crop.Height}
+	}
+
+	if g.Rotate == Rotate90 || g.Rotate == Rotate270 {
+		src = Size{Width: src.Height, Height: src.Width}
+	}
+
+	ratio := float64(src.Width) / float64(src.Height)
+	out := Size{Width: g.width, Height: g.height}
+	switch {
+	case out.Width == 0 && out.Height == 0:
+		out = src
+	case out.Height == 0:
+		out.Height = roundDimension(float64(out.Width) / ratio)
+	case out.Width == 0:
+		out.Width = roundDimension(float64(out.Height) * ratio)
+	}
+
+	l := Layout{Size: out, Content: image.Rect(0, 0, int(out.Width), int(out.Height))}
+	if g.Fit == FitContain {
+		// the picture is scaled down to fit the frame and centered, the rest is padding
+		w, h := float64(out.Width), float64(out.Height)
+		if w/h > ratio {
+			w = h * ratio
+		} else {
+			h = w / ratio
+		}
+		cw, ch := int(math.Round(w)), int(math.Round(h))
+		x, y := (int(out.Width)-cw)/2, (int(out.Height)-ch)/2
+		l.Content = image.Rect(x, y, x+cw, y+ch)
+	}
+	return l, nil
+}
+
+func roundDimension(v float64) uint {
+	if v < 1 {
+		return 1
+	}
+	return uint(math.Round(v))
+}
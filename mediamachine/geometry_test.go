@@ -0,0 +1,70 @@
+package mediamachine_test
+
+import (
+	"image"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/stackrock/mediamachinego/mediamachine"
+)
+
+var _ = Describe("OutputLayout", func() {
+	input := mediamachine.Size{Width: 1920, Height: 1080}
+
+	It("keeps the input size by default", func() {
+		l, err := mediamachine.TranscodeConfig{}.OutputLayout(input)
+		Expect(err).To(BeNil())
+		Expect(l.Size).To(Equal(input))
+		Expect(l.Content).To(Equal(image.Rect(0, 0, 1920, 1080)))
+	})
+
+	It("keeps the aspect ratio when only Width is set", func() {
+		l, err := mediamachine.ThumbnailConfig{Width: 640}.OutputLayout(input)
+		Expect(err).To(BeNil())
+		Expect(l.Size).To(Equal(mediamachine.Size{Width: 640, Height: 360}))
+	})
+
+	It("letterboxes the picture with FitContain", func() {
+		l, err := mediamachine.TranscodeConfig{Width: 1000, Height: 1000, Picture: mediamachine.Picture{Fit: mediamachine.FitContain}}.OutputLayout(input)
+		Expect(err).To(BeNil())
+		Expect(l.Size).To(Equal(mediamachine.Size{Width: 1000, Height: 1000}))
+		Expect(l.Content).To(Equal(image.Rect(0, 218, 1000, 781)))
+	})
+
+	It("fills the frame with FitCover", func() {
+		l, err := mediamachine.SummaryConfig{Width: 500, Height: 500, Picture: mediamachine.Picture{Fit: mediamachine.FitCover}}.OutputLayout(input)
+		Expect(err).To(BeNil())
+		Expect(l.Content).To(Equal(image.Rect(0, 0, 500, 500)))
+	})
+
+	It("applies crop and rotation before resizing", func() {
+		l, err := mediamachine.TranscodeConfig{
+			Width: 300,
+			Picture: mediamachine.Picture{
+				Crop:   &mediamachine.Crop{X: 100, Y: 0, Width: 800, Height: 600},
+				Rotate: mediamachine.Rotate90,
+			},
+		}.OutputLayout(input)
+		Expect(err).To(BeNil())
+		Expect(l.Size).To(Equal(mediamachine.Size{Width: 300, Height: 400}))
+	})
+
+	It("rejects invalid combinations", func() {
+		_, err := mediamachine.TranscodeConfig{Width: 500, Picture: mediamachine.Picture{Fit: mediamachine.FitCover}}.OutputLayout(input)
+		Expect(err).NotTo(BeNil())
+
+		_, err = mediamachine.TranscodeConfig{Width: 500, Height: 500, Picture: mediamachine.Picture{PadColor: "black"}}.OutputLayout(input)
+		Expect(err).NotTo(BeNil())
+
+		_, err = mediamachine.TranscodeConfig{Picture: mediamachine.Picture{Crop: &mediamachine.Crop{X: 1900, Width: 100, Height: 100}}}.OutputLayout(input)
+		Expect(err).NotTo(BeNil())
+
+		_, err = mediamachine.TranscodeConfig{Width: 500, Height: 500, Picture: mediamachine.Picture{Fit: mediamachine.FitContain, PadColor: "notacolor"}}.OutputLayout(input)
+		Expect(err).To(MatchError(ContainSubstring("PadColor")))
+
+		// X + Width overflows uint
+		_, err = mediamachine.TranscodeConfig{Picture: mediamachine.Picture{Crop: &mediamachine.Crop{X: ^uint(0) - 10, Width: 100, Height: 100}}}.OutputLayout(input)
+		Expect(err).NotTo(BeNil())
+	})
+})
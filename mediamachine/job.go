@@ -28,13 +28,64 @@ type Job struct {
 
 	lastStatusFetch time.Time
 	minFresh        time.Duration
-	status          string
+	status          JobStatus
+}
+
+// JobStatus holds the details of a job returned by the MediaMachine API
+type JobStatus struct {
+	Status  string      // One of JobStatusQueued, JobStatusErrored or JobStatusDone
+	Error   string      // Details of the failure when Status is JobStatusErrored
+	Outputs []JobOutput // Files produced by the job, listed once Status is JobStatusDone
+}
+
+// JobOutput is a file produced by a job
+type JobOutput struct {
+	URL       string           // Location the file was uploaded to
+	Index     int              // Position of the file among the outputs of the job, starting at 0
+	Timestamp time.Duration    // Position in the input video of the frame used for a thumbnail, sent in milliseconds
+	Scores    *FrameScores     // Quality scores of the frame picked by ThumbnailModeBest, nil for other outputs
+	Segments  []SummarySegment // Ranges of the input video used by a summary, in the order they are played
+}
+
+// UnmarshalJSON decodes a JobOutput returned by the MediaMachine API, which sends Timestamp in milliseconds,
+// the same unit as the {timestamp} placeholder of OutputURL, and the times of Segments in milliseconds as well
+func (o *JobOutput) UnmarshalJSON(data []byte) error {
+	type jobOutput JobOutput
+	out := struct {
+		*jobOutput
+		Timestamp int64
+		Segments  []struct {
+			SourceStart int64
+			SourceEnd   int64
+			OutputStart int64
+		}
+	}{jobOutput: (*jobOutput)(o)}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return err
+	}
+	o.Timestamp = time.Duration(out.Timestamp) * time.Millisecond
+	o.Segments = nil
+	for _, seg := range out.Segments {
+		o.Segments = append(o.Segments, SummarySegment{
+			SourceStart: time.Duration(seg.SourceStart) * time.Millisecond,
+			SourceEnd:   time.Duration(seg.SourceEnd) * time.Millisecond,
+			OutputStart: time.Duration(seg.OutputStart) * time.Millisecond,
+		})
+	}
+	return nil
 }
 
 // FetchStatus queries the MediaMachine API backend for the latest status for this job
 func (j Job) FetchStatus() (string, error) {
+	status, err := j.FetchDetails()
+	return status.Status, err
+}
+
+// FetchDetails queries the MediaMachine API backend for the latest status for this job,
+// along with the files it produced
+func (j Job) FetchDetails() (JobStatus, error) {
 	if j.ID == "" {
-		return "", fmt.Errorf("cannot fetch job status: ID is not set")
+		return JobStatus{}, fmt.Errorf("cannot fetch job status: ID is not set")
 	}
 
 	if time.Now().Sub(j.lastStatusFetch) < j.minFresh {
@@ -44,7 +95,7 @@ func (j Job) FetchStatus() (string, error) {
 
 	resp, err := httpClient.Get(fmt.Sprintf("%s/job/status?reqId=%s", apiEndpoint, j.ID))
 	if err != nil {
-		return "", err
+		return JobStatus{}, err
 	}
 	j.lastStatusFetch = time.Now()
 
@@ -60,17 +111,18 @@ func (j Job) FetchStatus() (string, error) {
 		}
 	}
 
-	payload := map[string]string{}
+	payload := JobStatus{}
 	err = json.NewDecoder(resp.Body).Decode(&payload)
 	if err != nil {
-		return "", err
+		return JobStatus{}, err
 	}
-	if payload["error"] != "" {
+	if payload.Error != "" {
 		// set the new job status on the status obj as well
-		j.status = JobStatusErrored
+		payload.Status = JobStatusErrored
+		j.status = payload
 		return j.status, fmt.Errorf("job %s errored", j.ID)
 	}
 	// set the new job status on the status obj as well
-	j.status = payload["status"]
+	j.status = payload
 	return j.status, nil
 }
@@ -1,6 +1,8 @@
 package mediamachine
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -19,6 +21,8 @@ const (
 // MediaMachine gives you access to the various operations you can perform using the API.
 type MediaMachine struct {
 	APIKey string // Your API key goes here
+
+	Presets *PresetRegistry // Optional - registry used to resolve TranscodeConfig.Preset, defaults to DefaultPresets
 }
 
 var httpClient = http.Client{Timeout: time.Second * 10}
@@ -63,3 +67,43 @@ func (m MediaMachine) submit(path string, body io.Reader) (Job, error) {
 
 	return j, fmt.Errorf("unexpected server response: %s", payload)
 }
+
+// call sends a JSON payload to the MediaMachine API and decodes the JSON response into out, unless out is nil.
+func (m MediaMachine) call(ctx context.Context, path string, payload interface{}, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", apiEndpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", ua)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error != "" {
+		return fmt.Errorf("MediaMachine API request failed. Error: %s", apiErr.Error)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected server response: %s", respBody)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
@@ -0,0 +1,299 @@
+package mediamachine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// PresetWeb1080p is a built-in preset for 1080p web playback
+	PresetWeb1080p = "web-1080p"
+	// PresetMobile480p is a built-in preset for 480p playback on mobile networks
+	PresetMobile480p = "mobile-480p"
+	// PresetSocialSquare is a built-in preset for square 1080x1080 social media posts
+	PresetSocialSquare = "social-square"
+	// PresetArchiveHigh is a built-in preset for high quality ProRes archival copies
+	PresetArchiveHigh = "archive-high"
+)
+
+var presetNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+/*
+Preset is a named set of TranscodeConfig output settings.
+
+Presets are referenced from TranscodeConfig.Preset. Any field set on the TranscodeConfig itself
+takes precedence over the value from the preset.
+*/
+type Preset struct {
+	Name string
+
+	Container   TranscodeContainer
+	Encoder     TranscodeEncoder
+	BitrateKBPS TranscodeBitrate
+
+	Width    uint
+	Height   uint
+	Fit      FitMode
+	PadColor string
+	CropMode CropMode
+
+	FrameRate              float64
+	MaxFrameRate           float64
+	KeyframeInterval       time.Duration
+	KeyframeIntervalFrames uint
+	ClosedGOP              bool
+	DisableSceneCut        bool
+}
+
+/*
+Apply returns a copy of cfg with every unset field filled in from the preset.
+
+Boolean fields can only be switched on by a preset, a TranscodeConfig cannot switch them back off.
+*/
+func (p Preset) Apply(cfg TranscodeConfig) TranscodeConfig {
+	if cfg.Container == "" {
+		cfg.Container = p.Container
+	}
+	if cfg.Encoder == "" {
+		cfg.Encoder = p.Encoder
+	}
+	if cfg.BitrateKBPS == "" {
+		cfg.BitrateKBPS = p.BitrateKBPS
+	}
+	if cfg.Width == 0 && cfg.Height == 0 {
+		// sizes are only taken as a pair, so that a caller setting Width keeps the input aspect ratio.
+		// Fit, PadColor and CropMode belong to the preset size, a caller setting its own size
+		// sets them as well.
+		cfg.Width, cfg.Height = p.Width, p.Height
+		if cfg.Fit == "" {
+			cfg.Fit = p.Fit
+		}
+		if cfg.PadColor == "" {
+			cfg.PadColor = p.PadColor
+		}
+		if cfg.CropMode == "" {
+			cfg.CropMode = p.CropMode
+		}
+	}
+	if cfg.FrameRate == 0 && cfg.MaxFrameRate == 0 {
+		cfg.FrameRate, cfg.MaxFrameRate = p.FrameRate, p.MaxFrameRate
+	}
+	if cfg.KeyframeInterval == 0 && cfg.KeyframeIntervalFrames == 0 {
+		cfg.KeyframeInterval, cfg.KeyframeIntervalFrames = p.KeyframeInterval, p.KeyframeIntervalFrames
+	}
+	cfg.ClosedGOP = cfg.ClosedGOP || p.ClosedGOP
+	cfg.DisableSceneCut = cfg.DisableSceneCut || p.DisableSceneCut
+	return cfg
+}
+
+func (p Preset) validate() error {
+	if !presetNamePattern.MatchString(p.Name) {
+		return fmt.Errorf("invalid preset name: '%s'", p.Name)
+	}
+	if err := validateCodecs(p.Container, p.Encoder); err != nil {
+		return fmt.Errorf("preset '%s': %s", p.Name, err)
+	}
+	cfg := p.Apply(TranscodeConfig{})
+	if err := cfg.geometry().validate(); err != nil {
+		return fmt.Errorf("preset '%s': %s", p.Name, err)
+	}
+	if err := validateFrameControls(cfg); err != nil {
+		return fmt.Errorf("preset '%s': %s", p.Name, err)
+	}
+	return nil
+}
+
+// PresetRegistry holds named presets. It is safe for concurrent use.
+type PresetRegistry struct {
+	mu      sync.RWMutex
+	presets map[string]Preset
+}
+
+// DefaultPresets is the registry used by MediaMachine when its Presets field is nil.
+var DefaultPresets = NewPresetRegistry()
+
+// NewPresetRegistry returns a registry holding the built-in presets.
+func NewPresetRegistry() *PresetRegistry {
+	r := &PresetRegistry{presets: map[string]Preset{}}
+	for _, p := range builtinPresets {
+		r.presets[p.Name] = p
+	}
+	return r
+}
+
+var builtinPresets = []Preset{
+	{
+		Name:             PresetWeb1080p,
+		Container:        ContainerMP4,
+		Encoder:          EncoderH264,
+		BitrateKBPS:      Bitrate4Mbps,
+		Width:            1920,
+		Height:           1080,
+		Fit:              FitContain,
+		KeyframeInterval: time.Second * 2,
+	},
+	{
+		Name:             PresetMobile480p,
+		Container:        ContainerMP4,
+		Encoder:          EncoderH264,
+		BitrateKBPS:      Bitrate1Mbps,
+		Width:            854,
+		Height:           480,
+		Fit:              FitContain,
+		MaxFrameRate:     30,
+		KeyframeInterval: time.Second * 2,
+	},
+	{
+		Name:        PresetSocialSquare,
+		Container:   ContainerMP4,
+		Encoder:     EncoderH264,
+		BitrateKBPS: Bitrate2Mbps,
+		Width:       1080,
+		Height:      1080,
+		Fit:         FitCover,
+		CropMode:    CropSmart,
+	},
+	{
+		Name:      PresetArchiveHigh,
+		Container: ContainerMOV,
+		Encoder:   EncoderProRes,
+	},
+}
+
+// Register adds a preset to the registry, replacing any preset with the same name.
+func (r *PresetRegistry) Register(p Preset) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.presets[p.Name] = p
+	return nil
+}
+
+// Lookup returns the preset registered under name.
+func (r *PresetRegistry) Lookup(name string) (Preset, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.presets[name]
+	return p, ok
+}
+
+// Names returns the sorted names of all registered presets.
+func (r *PresetRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.presets))
+	for name := range r.presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+LoadJSON registers the presets read from a JSON document mapping preset names to their settings:
+
+	{
+	  "house-web": {"container": "mp4", "encoder": "h264", "bitrateKBPS": "2000", "width": 1280, "height": 720},
+	  "house-hls": {"container": "ts", "encoder": "h264", "keyframeInterval": "2s", "closedGOP": true}
+	}
+
+Nothing is registered if any of the presets is invalid.
+*/
+func (r *PresetRegistry) LoadJSON(rd io.Reader) error {
+	var file map[string]presetFileEntry
+	dec := json.NewDecoder(rd)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return err
+	}
+	return r.registerFile(file)
+}
+
+// LoadYAML registers the presets read from a YAML document, using the same layout as LoadJSON.
+func (r *PresetRegistry) LoadYAML(rd io.Reader) error {
+	data, err := ioutil.ReadAll(rd)
+	if err != nil {
+		return err
+	}
+	var file map[string]presetFileEntry
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return err
+	}
+	return r.registerFile(file)
+}
+
+func (r *PresetRegistry) registerFile(file map[string]presetFileEntry) error {
+	presets := make([]Preset, 0, len(file))
+	for name, entry := range file {
+		p, err := entry.preset(name)
+		if err != nil {
+			return err
+		}
+		if err := p.validate(); err != nil {
+			return err
+		}
+		presets = append(presets, p)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range presets {
+		r.presets[p.Name] = p
+	}
+	return nil
+}
+
+// presetFileEntry is the layout of a preset in JSON/YAML preset files
+type presetFileEntry struct {
+	Container              string  `json:"container" yaml:"container"`
+	Encoder                string  `json:"encoder" yaml:"encoder"`
+	BitrateKBPS            string  `json:"bitrateKBPS" yaml:"bitrateKBPS"`
+	Width                  uint    `json:"width" yaml:"width"`
+	Height                 uint    `json:"height" yaml:"height"`
+	Fit                    string  `json:"fit" yaml:"fit"`
+	PadColor               string  `json:"padColor" yaml:"padColor"`
+	CropMode               string  `json:"cropMode" yaml:"cropMode"`
+	FrameRate              float64 `json:"frameRate" yaml:"frameRate"`
+	MaxFrameRate           float64 `json:"maxFrameRate" yaml:"maxFrameRate"`
+	KeyframeInterval       string  `json:"keyframeInterval" yaml:"keyframeInterval"` // parsed with time.ParseDuration
+	KeyframeIntervalFrames uint    `json:"keyframeIntervalFrames" yaml:"keyframeIntervalFrames"`
+	ClosedGOP              bool    `json:"closedGOP" yaml:"closedGOP"`
+	DisableSceneCut        bool    `json:"disableSceneCut" yaml:"disableSceneCut"`
+}
+
+func (e presetFileEntry) preset(name string) (Preset, error) {
+	p := Preset{
+		Name:                   name,
+		Container:              TranscodeContainer(e.Container),
+		Encoder:                TranscodeEncoder(e.Encoder),
+		BitrateKBPS:            e.BitrateKBPS,
+		Width:                  e.Width,
+		Height:                 e.Height,
+		Fit:                    e.Fit,
+		PadColor:               e.PadColor,
+		CropMode:               e.CropMode,
+		FrameRate:              e.FrameRate,
+		MaxFrameRate:           e.MaxFrameRate,
+		KeyframeIntervalFrames: e.KeyframeIntervalFrames,
+		ClosedGOP:              e.ClosedGOP,
+		DisableSceneCut:        e.DisableSceneCut,
+	}
+	if e.KeyframeInterval != "" {
+		d, err := time.ParseDuration(e.KeyframeInterval)
+		if err != nil {
+			return Preset{}, fmt.Errorf("preset '%s': invalid keyframeInterval: %s", name, err)
+		}
+		p.KeyframeInterval = d
+	}
+	return p, nil
+}
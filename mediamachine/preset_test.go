@@ -0,0 +1,75 @@
+package mediamachine_test
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/stackrock/mediamachinego/mediamachine"
+)
+
+var _ = Describe("PresetRegistry", func() {
+	It("provides the built-in presets", func() {
+		r := mediamachine.NewPresetRegistry()
+		Expect(r.Names()).To(ConsistOf(
+			mediamachine.PresetWeb1080p,
+			mediamachine.PresetMobile480p,
+			mediamachine.PresetSocialSquare,
+			mediamachine.PresetArchiveHigh,
+		))
+	})
+
+	It("lets per-call settings override the preset", func() {
+		p, ok := mediamachine.NewPresetRegistry().Lookup(mediamachine.PresetWeb1080p)
+		Expect(ok).To(BeTrue())
+
+		cfg := p.Apply(mediamachine.TranscodeConfig{
+			BitrateKBPS: mediamachine.Bitrate2Mbps,
+			Width:       1280,
+			InputURL:    "https://example.com/input.mp4",
+			OutputURL:   "https://example.com/output",
+		})
+		Expect(cfg.Container).To(Equal(mediamachine.ContainerMP4))
+		Expect(cfg.BitrateKBPS).To(Equal(mediamachine.Bitrate2Mbps))
+		Expect(cfg.Width).To(Equal(uint(1280)))
+		Expect(cfg.Height).To(Equal(uint(0)))
+		Expect(cfg.Fit).To(BeEmpty())
+
+		restore := mediamachine.UseTransport(&fakeAPI{response: `{"id":"job-1"}`})
+		defer restore()
+		_, err := mediamachine.MediaMachine{APIKey: "key"}.Transcode(cfg)
+		Expect(err).To(BeNil())
+	})
+
+	It("loads presets from YAML", func() {
+		r := mediamachine.NewPresetRegistry()
+		err := r.LoadYAML(strings.NewReader(`
+house-hls:
+  container: ts
+  encoder: h264
+  bitrateKBPS: "2000"
+  keyframeInterval: 2s
+  closedGOP: true
+`))
+		Expect(err).To(BeNil())
+
+		p, ok := r.Lookup("house-hls")
+		Expect(ok).To(BeTrue())
+		Expect(p.Container).To(Equal(mediamachine.ContainerMPEGTS))
+		Expect(p.KeyframeInterval).To(Equal(time.Second * 2))
+		Expect(p.ClosedGOP).To(BeTrue())
+	})
+
+	It("rejects invalid presets from JSON", func() {
+		r := mediamachine.NewPresetRegistry()
+		err := r.LoadJSON(strings.NewReader(`{"bad": {"container": "webm", "encoder": "h264"}}`))
+		Expect(err).NotTo(BeNil())
+		_, ok := r.Lookup("bad")
+		Expect(ok).To(BeFalse())
+
+		err = r.LoadJSON(strings.NewReader(`{"typo": {"contianer": "mp4"}}`))
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,188 @@
+package mediamachine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// HDRFormat is the high dynamic range format of a video track
+type HDRFormat = string
+
+const (
+	// HDRNone is a standard dynamic range video track
+	HDRNone HDRFormat = ""
+	// HDR10 is an HDR10 video track, using the PQ transfer function with static metadata
+	HDR10 HDRFormat = "hdr10"
+	// HDR10Plus is an HDR10+ video track, using the PQ transfer function with dynamic metadata
+	HDR10Plus HDRFormat = "hdr10+"
+	// HDRHLG is a hybrid log-gamma video track
+	HDRHLG HDRFormat = "hlg"
+	// HDRDolbyVision is a Dolby Vision video track
+	HDRDolbyVision HDRFormat = "dolby_vision"
+)
+
+// ProbeConfig configures the request for inspecting the metadata of the input video
+type ProbeConfig struct {
+	// Structured as {http|https|s3|azure|gcp}://{bucket-name}/{prefix-if-any}/{object-name}
+	// Examples: s3://bucket/prefix/input.mp4, https://example.com/files/input.mp4
+	InputURL string
+
+	// Provide credentials to S3/Azure/GCP for the input location
+	// Can be nil if using http(s) input url - make sure url endpoint is accessible
+	InputCreds Creds
+}
+
+// MediaInfo is the metadata of a media file, as returned by Probe
+type MediaInfo struct {
+	Container   string        // Name of the container format, e.g. mp4 or webm
+	Duration    time.Duration // Duration of the longest track, sent by the API in milliseconds
+	SizeBytes   int64         // Size of the file
+	BitrateKBPS uint          // Overall bitrate of the file
+
+	VideoTracks []VideoTrack
+	AudioTracks []AudioTrack
+}
+
+// VideoTrack is the metadata of a video track of a media file
+type VideoTrack struct {
+	Index       int     // Position of the track in the file
+	Codec       string  // e.g. h264, hevc, vp9 or av1
+	Profile     string  // Optional - codec profile, e.g. High or Main 10
+	Width       uint    // Width in pixels of the encoded picture, before Rotation
+	Height      uint    // Height in pixels of the encoded picture, before Rotation
+	FrameRate   float64 // Average number of frames per second
+	BitrateKBPS uint
+	Rotation    int // Clockwise rotation in degrees players apply to the picture: 0, 90, 180 or 270
+
+	PixelFormat             string    // e.g. yuv420p or yuv420p10le
+	BitDepth                uint      // Number of bits per color component, e.g. 8 or 10
+	ColorPrimaries          string    // e.g. bt709 or bt2020
+	TransferCharacteristics string    // e.g. bt709, smpte2084 (PQ) or arib-std-b67 (HLG)
+	HDR                     HDRFormat // HDRNone for standard dynamic range
+}
+
+// UnmarshalJSON decodes a MediaInfo returned by the MediaMachine API, which sends Duration in milliseconds
+func (i *MediaInfo) UnmarshalJSON(data []byte) error {
+	type mediaInfo MediaInfo
+	info := struct {
+		*mediaInfo
+		Duration int64
+	}{mediaInfo: (*mediaInfo)(i)}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return err
+	}
+	i.Duration = time.Duration(info.Duration) * time.Millisecond
+	return nil
+}
+
+// AudioTrack is the metadata of an audio track of a media file
+type AudioTrack struct {
+	Index         int    // Position of the track in the file
+	Codec         string // e.g. aac, opus or mp3
+	Channels      uint
+	ChannelLayout string // e.g. stereo or 5.1
+	SampleRate    uint   // in Hz
+	BitrateKBPS   uint
+	Language      string // Optional - language tag of the track, e.g. en
+}
+
+/*
+Probe inspects the input and returns its metadata: duration, resolution, codecs, bitrates, frame rate, rotation,
+audio tracks and HDR format.
+
+Unlike the other operations, Probe does not create a job, the metadata is returned directly.
+*/
+func (m MediaMachine) Probe(ctx context.Context, cfg ProbeConfig) (MediaInfo, error) {
+	if err := validateInput(cfg.InputURL, cfg.InputCreds); err != nil {
+		return MediaInfo{}, err
+	}
+
+	req := struct {
+		APIKey string
+		ProbeConfig
+	}{
+		APIKey:      m.APIKey,
+		ProbeConfig: cfg,
+	}
+	var info MediaInfo
+	err := m.call(ctx, "/probe", req, &info)
+	return info, err
+}
+
+// HasVideo reports whether the media has at least one video track.
+func (i MediaInfo) HasVideo() bool {
+	return len(i.VideoTracks) > 0
+}
+
+/*
+DisplaySize returns the size of the picture of the first video track as shown by players, i.e. with Rotation applied.
+This is the input size to use with OutputLayout when Rotate is RotateAuto.
+
+Returns a zero Size when the media has no video track.
+*/
+func (i MediaInfo) DisplaySize() Size {
+	if !i.HasVideo() {
+		return Size{}
+	}
+	v := i.VideoTracks[0]
+	if v.Rotation%180 != 0 {
+		return Size{Width: v.Height, Height: v.Width}
+	}
+	return Size{Width: v.Width, Height: v.Height}
+}
+
+// inputSize returns the size of the first video track to use for OutputLayout, see Rotation
+func (i MediaInfo) inputSize(rotate Rotation) Size {
+	if rotate == RotateAuto || !i.HasVideo() {
+		return i.DisplaySize()
+	}
+	return Size{Width: i.VideoTracks[0].Width, Height: i.VideoTracks[0].Height}
+}
+
+/*
+CheckInput checks the configuration against the metadata of the input, e.g. as read locally by the probe package,
+so that a job that would fail is not submitted: the input must have the tracks needed by Container, and Crop must
+fit in the input picture.
+*/
+func (cfg TranscodeConfig) CheckInput(info MediaInfo) error {
+	if cfg.Container.IsAudioOnly() || cfg.Encoder.IsAudio() {
+		if len(info.AudioTracks) == 0 {
+			return fmt.Errorf("input has no audio track")
+		}
+		return nil
+	}
+	if !info.HasVideo() {
+		return fmt.Errorf("input has no video track")
+	}
+	_, err := cfg.OutputLayout(info.inputSize(cfg.Rotate))
+	return err
+}
+
+/*
+CheckInput checks the configuration against the metadata of the input, e.g. as read locally by the probe package,
+so that a job that would fail is not submitted: the input must have a video track, Timestamps and the Selection
+window must be within its duration, SkipStart and SkipEnd must leave some of it, and Crop must fit in the input picture.
+*/
+func (cfg ThumbnailConfig) CheckInput(info MediaInfo) error {
+	if !info.HasVideo() {
+		return fmt.Errorf("input has no video track")
+	}
+	if info.Duration > 0 {
+		for _, ts := range cfg.Timestamps {
+			if ts >= info.Duration {
+				return fmt.Errorf("thumbnail timestamp %s is after the end of the input (%s)", ts, info.Duration)
+			}
+		}
+		if cfg.Selection != nil && cfg.Selection.SearchStart >= info.Duration {
+			return fmt.Errorf("thumbnail Selection SearchStart %s is after the end of the input (%s)",
+				cfg.Selection.SearchStart, info.Duration)
+		}
+		if cfg.Selection != nil && cfg.Selection.SkipStart+cfg.Selection.SkipEnd >= info.Duration {
+			return fmt.Errorf("thumbnail Selection SkipStart and SkipEnd leave no frame of the input (%s)", info.Duration)
+		}
+	}
+	_, err := cfg.OutputLayout(info.inputSize(cfg.Rotate))
+	return err
+}
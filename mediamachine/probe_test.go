@@ -0,0 +1,82 @@
+package mediamachine_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/stackrock/mediamachinego/mediamachine"
+)
+
+var _ = Describe("Probe", func() {
+	It("validates the input before calling the API", func() {
+		_, err := mediamachine.MediaMachine{}.Probe(context.Background(), mediamachine.ProbeConfig{InputURL: "s3://bucket/input.mp4"})
+		Expect(err).To(MatchError(ContainSubstring("inputCreds")))
+
+		_, err = mediamachine.MediaMachine{}.Probe(context.Background(), mediamachine.ProbeConfig{InputURL: "ftp://example.com/input.mp4"})
+		Expect(err).To(MatchError(ContainSubstring("unsupported scheme")))
+	})
+
+	It("decodes the metadata returned by the API", func() {
+		api := &fakeAPI{response: `{
+			"Container": "mp4", "Duration": 12345, "SizeBytes": 1048576, "BitrateKBPS": 680,
+			"VideoTracks": [{
+				"Index": 0, "Codec": "hevc", "Profile": "Main 10", "Width": 3840, "Height": 2160,
+				"FrameRate": 23.976, "BitrateKBPS": 550, "Rotation": 90,
+				"PixelFormat": "yuv420p10le", "BitDepth": 10, "ColorPrimaries": "bt2020",
+				"TransferCharacteristics": "smpte2084", "HDR": "hdr10"
+			}],
+			"AudioTracks": [{
+				"Index": 1, "Codec": "aac", "Channels": 2, "ChannelLayout": "stereo",
+				"SampleRate": 48000, "BitrateKBPS": 128, "Language": "en"
+			}]
+		}`}
+		defer mediamachine.UseTransport(api)()
+
+		info, err := mediamachine.MediaMachine{APIKey: "key"}.Probe(context.Background(),
+			mediamachine.ProbeConfig{InputURL: "https://example.com/input.mp4"})
+		Expect(err).To(BeNil())
+		Expect(api.body).To(HaveKeyWithValue("InputURL", "https://example.com/input.mp4"))
+		Expect(info).To(Equal(mediamachine.MediaInfo{
+			Container:   "mp4",
+			Duration:    12345 * time.Millisecond,
+			SizeBytes:   1048576,
+			BitrateKBPS: 680,
+			VideoTracks: []mediamachine.VideoTrack{{
+				Codec:                   "hevc",
+				Profile:                 "Main 10",
+				Width:                   3840,
+				Height:                  2160,
+				FrameRate:               23.976,
+				BitrateKBPS:             550,
+				Rotation:                90,
+				PixelFormat:             "yuv420p10le",
+				BitDepth:                10,
+				ColorPrimaries:          "bt2020",
+				TransferCharacteristics: "smpte2084",
+				HDR:                     mediamachine.HDR10,
+			}},
+			AudioTracks: []mediamachine.AudioTrack{{
+				Index:         1,
+				Codec:         "aac",
+				Channels:      2,
+				ChannelLayout: "stereo",
+				SampleRate:    48000,
+				BitrateKBPS:   128,
+				Language:      "en",
+			}},
+		}))
+	})
+
+	It("applies the rotation to the display size", func() {
+		info := mediamachine.MediaInfo{VideoTracks: []mediamachine.VideoTrack{{Width: 1920, Height: 1080, Rotation: 90}}}
+		Expect(info.DisplaySize()).To(Equal(mediamachine.Size{Width: 1080, Height: 1920}))
+
+		info.VideoTracks[0].Rotation = 180
+		Expect(info.DisplaySize()).To(Equal(mediamachine.Size{Width: 1920, Height: 1080}))
+
+		Expect(mediamachine.MediaInfo{}.DisplaySize()).To(Equal(mediamachine.Size{}))
+	})
+})
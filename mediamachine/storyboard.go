@@ -0,0 +1,182 @@
+package mediamachine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultStoryboardInterval is the time between two tiles of a storyboard when Interval is not set
+	DefaultStoryboardInterval = 5 * time.Second
+	// DefaultStoryboardColumns is the number of tiles per row of a sprite sheet when Columns is not set
+	DefaultStoryboardColumns = 5
+	// DefaultStoryboardRows is the number of rows of tiles of a sprite sheet when Rows is not set
+	DefaultStoryboardRows = 5
+
+	// MinStoryboardInterval is the shortest Interval of a storyboard
+	MinStoryboardInterval = 500 * time.Millisecond
+	// MaxSpriteSheetSize is the largest width or height in pixels of a sprite sheet
+	MaxSpriteSheetSize = 8192
+)
+
+/*
+StoryboardConfig configures the request for the sprite sheets and WebVTT file used for scrubbing previews in players.
+
+One tile is taken from the input video every Interval. The tiles are laid out left to right, then top to bottom, on
+sprite sheets of Columns x Rows tiles. The sheets are uploaded to OutputURL, which must contain the {index}
+placeholder (see ExpandOutputURL, {timestamp} is the position of the first tile of the sheet), and the WebVTT file
+referencing each tile with a #xywh= fragment is uploaded to VTTURL.
+
+If TileHeight is not set, it is calculated from TileWidth according to input aspect ratio.
+*/
+type StoryboardConfig struct {
+	// Structured as {http|https|s3|azure|gcp}://{bucket-name}/{prefix-if-any}/{object-name}
+	// Examples: s3://bucket/prefix/input.mp4, https://example.com/files/input.mp4
+	InputURL  string
+	OutputURL string
+	VTTURL    string
+
+	// Provide credentials to S3/Azure/GCP for input/output locations
+	// Can be nil if using http(s) input/output urls - make sure url endpoints are accessible
+	// Note: OutputCreds are used for both OutputURL and VTTURL.
+	InputCreds  Creds
+	OutputCreds Creds
+
+	Interval   time.Duration `json:"-"` // Optional - defaults to DefaultStoryboardInterval, sent in whole milliseconds
+	TileWidth  uint          // Width of a tile in pixels
+	TileHeight uint          // Optional - by default, calculated from TileWidth according to input aspect ratio
+	Columns    uint          // Optional - defaults to DefaultStoryboardColumns
+	Rows       uint          // Optional - defaults to DefaultStoryboardRows
+
+	Format  ThumbnailFormat // Optional - image format of the sheets, defaults to the extension of OutputURL, or JPEG
+	Quality uint            // Optional - from 1 to 100, for JPEG, WebP and AVIF sheets
+
+	SuccessURL string // Optional - Expect a POST call when job is successfully finished
+	FailureURL string // Optional - Expect a POST call with failure details
+}
+
+/*
+Storyboard enqueues a request to the MediaMachine backend to asynchronously generate sprite sheets and a WebVTT
+storyboard for the input video.
+
+The sheets and the WebVTT file are uploaded to the locations specified in the StoryboardConfig.
+Errors if the input configuration is invalid.
+*/
+func (m MediaMachine) Storyboard(cfg StoryboardConfig) (Job, error) {
+	if err := cfg.validate(); err != nil {
+		return Job{}, err
+	}
+
+	sr := struct {
+		APIKey string
+		StoryboardConfig
+		IntervalMS int64
+	}{
+		APIKey:           m.APIKey,
+		StoryboardConfig: cfg,
+		IntervalMS:       cfg.Interval.Milliseconds(),
+	}
+
+	body, err := json.Marshal(sr)
+	if err != nil {
+		return Job{}, err
+	}
+	return m.submit("/storyboard", bytes.NewBuffer(body))
+}
+
+func (cfg StoryboardConfig) validate() error {
+	if err := validateInputOutput(cfg.InputURL, cfg.OutputURL, cfg.InputCreds, cfg.OutputCreds); err != nil {
+		return err
+	}
+	if err := validateOutput(cfg.VTTURL, cfg.OutputCreds); err != nil {
+		return err
+	}
+	if !strings.Contains(cfg.OutputURL, outputIndexPlaceholder) {
+		return fmt.Errorf("storyboard OutputURL must contain %s", outputIndexPlaceholder)
+	}
+
+	if cfg.Interval != 0 && cfg.Interval < MinStoryboardInterval {
+		return fmt.Errorf("storyboard Interval must be at least %s", MinStoryboardInterval)
+	}
+	if cfg.TileWidth == 0 {
+		return fmt.Errorf("storyboard TileWidth must be set")
+	}
+	if err := cfg.validateSheetSize(cfg.TileHeight); err != nil {
+		return err
+	}
+
+	thumbnail := ThumbnailConfig{OutputURL: cfg.OutputURL, Format: cfg.Format, Quality: cfg.Quality}
+	return thumbnail.validateFormat("storyboard")
+}
+
+// validateSheetSize checks the size of the sprite sheets for tiles of the given height,
+// which is 0 when it is not known yet
+func (cfg StoryboardConfig) validateSheetSize(tileHeight uint) error {
+	columns, rows := cfg.grid()
+	if columns*cfg.TileWidth > MaxSpriteSheetSize || rows*tileHeight > MaxSpriteSheetSize {
+		return fmt.Errorf("storyboard sprite sheets cannot be larger than %dx%d", MaxSpriteSheetSize, MaxSpriteSheetSize)
+	}
+	return nil
+}
+
+func (cfg StoryboardConfig) grid() (uint, uint) {
+	columns, rows := cfg.Columns, cfg.Rows
+	if columns == 0 {
+		columns = DefaultStoryboardColumns
+	}
+	if rows == 0 {
+		rows = DefaultStoryboardRows
+	}
+	return columns, rows
+}
+
+/*
+Build computes locally the storyboard that a job would produce for an input video of the given size and duration,
+e.g. to generate the WebVTT file without waiting for the job, or to check the number of sheets.
+input is only used to calculate TileHeight when it is not set.
+*/
+func (cfg StoryboardConfig) Build(input Size, duration time.Duration) (Storyboard, error) {
+	if err := cfg.validate(); err != nil {
+		return Storyboard{}, err
+	}
+	interval := cfg.Interval
+	if interval == 0 {
+		interval = DefaultStoryboardInterval
+	}
+	tileHeight := cfg.TileHeight
+	if tileHeight == 0 {
+		if input.Width == 0 || input.Height == 0 {
+			return Storyboard{}, fmt.Errorf("input size must be set to calculate TileHeight")
+		}
+		tileHeight = uint(math.Round(float64(input.Height) * float64(cfg.TileWidth) / float64(input.Width)))
+	}
+	if err := cfg.validateSheetSize(tileHeight); err != nil {
+		return Storyboard{}, err
+	}
+	columns, rows := cfg.grid()
+	perSheet := int(columns * rows)
+
+	var sb Storyboard
+	for i := 0; time.Duration(i)*interval < duration; i++ {
+		start := time.Duration(i) * interval
+		end := start + interval
+		if end > duration {
+			end = duration
+		}
+		sheet, tile := i/perSheet, i%perSheet
+		x := int(uint(tile)%columns) * int(cfg.TileWidth)
+		y := int(uint(tile)/columns) * int(tileHeight)
+		sb.Cues = append(sb.Cues, StoryboardCue{
+			Start: start,
+			End:   end,
+			URL:   ExpandOutputURL(cfg.OutputURL, sheet, time.Duration(sheet*perSheet)*interval),
+			Tile:  image.Rect(x, y, x+int(cfg.TileWidth), y+int(tileHeight)),
+		})
+	}
+	return sb, nil
+}
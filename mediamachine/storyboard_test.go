@@ -0,0 +1,111 @@
+package mediamachine_test
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/stackrock/mediamachinego/mediamachine"
+)
+
+var _ = Describe("Storyboard", func() {
+	cfg := mediamachine.StoryboardConfig{
+		InputURL:  "https://example.com/input.mp4",
+		OutputURL: "https://example.com/sprites/sheet-{index}.jpg",
+		VTTURL:    "https://example.com/sprites/storyboard.vtt",
+		Interval:  10 * time.Second,
+		TileWidth: 160,
+		Columns:   2,
+		Rows:      2,
+	}
+
+	It("builds the tiles of the sprite sheets", func() {
+		sb, err := cfg.Build(mediamachine.Size{Width: 1920, Height: 1080}, 45*time.Second)
+		Expect(err).To(BeNil())
+		Expect(sb.Cues).To(HaveLen(5))
+		Expect(sb.Cues[3]).To(Equal(mediamachine.StoryboardCue{
+			Start: 30 * time.Second,
+			End:   40 * time.Second,
+			URL:   "https://example.com/sprites/sheet-0.jpg",
+			Tile:  image.Rect(160, 90, 320, 180),
+		}))
+		Expect(sb.Cues[4].URL).To(Equal("https://example.com/sprites/sheet-1.jpg"))
+		Expect(sb.Cues[4].End).To(Equal(45 * time.Second))
+	})
+
+	It("writes and parses WebVTT files", func() {
+		sb, err := cfg.Build(mediamachine.Size{Width: 1920, Height: 1080}, 45*time.Second)
+		Expect(err).To(BeNil())
+
+		var buf bytes.Buffer
+		_, err = sb.WriteTo(&buf)
+		Expect(err).To(BeNil())
+		Expect(buf.String()).To(ContainSubstring(
+			"00:00:30.000 --> 00:00:40.000\nhttps://example.com/sprites/sheet-0.jpg#xywh=160,90,160,90\n"))
+
+		parsed, err := mediamachine.ParseStoryboard(&buf)
+		Expect(err).To(BeNil())
+		Expect(parsed).To(Equal(sb))
+	})
+
+	It("parses cue identifiers, notes and short timestamps", func() {
+		sb, err := mediamachine.ParseStoryboard(strings.NewReader("WEBVTT\r\n\r\nNOTE generated\r\n\r\n" +
+			"1\r\n00:05.000 --> 00:10.500 align:start\r\nsheet.jpg#xywh=0,0,10,20\r\n"))
+		Expect(err).To(BeNil())
+		Expect(sb.Cues).To(Equal([]mediamachine.StoryboardCue{{
+			Start: 5 * time.Second,
+			End:   10500 * time.Millisecond,
+			URL:   "sheet.jpg",
+			Tile:  image.Rect(0, 0, 10, 20),
+		}}))
+
+		rewritten := sb.RewriteURLs(func(u string) string { return "https://cdn.example.com/" + u })
+		Expect(rewritten.Cues[0].URL).To(Equal("https://cdn.example.com/sheet.jpg"))
+		Expect(sb.Cues[0].URL).To(Equal("sheet.jpg"))
+	})
+
+	It("rejects invalid files and configurations", func() {
+		_, err := mediamachine.ParseStoryboard(strings.NewReader("00:00.000 --> 00:01.000\na.jpg\n"))
+		Expect(err).NotTo(BeNil())
+		_, err = mediamachine.ParseStoryboard(strings.NewReader("WEBVTT\n\n00:00.000 --> 00:01.000\na.jpg#xywh=a,b\n"))
+		Expect(err).NotTo(BeNil())
+
+		invalid := cfg
+		invalid.OutputURL = "https://example.com/sprites/sheet.jpg"
+		_, err = mediamachine.MediaMachine{}.Storyboard(invalid)
+		Expect(err).NotTo(BeNil())
+
+		invalid = cfg
+		invalid.Columns = 100
+		_, err = mediamachine.MediaMachine{}.Storyboard(invalid)
+		Expect(err).NotTo(BeNil())
+
+		invalid = cfg
+		invalid.Quality = 101
+		_, err = mediamachine.MediaMachine{}.Storyboard(invalid)
+		Expect(err).To(MatchError(HavePrefix("storyboard Quality")))
+	})
+
+	It("checks the sheet size with a calculated TileHeight", func() {
+		tall := cfg
+		tall.Rows = 30
+		_, err := tall.Build(mediamachine.Size{Width: 1920, Height: 1080}, time.Minute)
+		Expect(err).To(BeNil())
+		_, err = tall.Build(mediamachine.Size{Width: 1080, Height: 1920}, time.Minute)
+		Expect(err).To(MatchError(ContainSubstring("cannot be larger than")))
+	})
+
+	It("sends the interval in milliseconds", func() {
+		api := &fakeAPI{response: `{"id":"job-1"}`}
+		defer mediamachine.UseTransport(api)()
+
+		_, err := mediamachine.MediaMachine{}.Storyboard(cfg)
+		Expect(err).To(BeNil())
+		Expect(api.body).To(HaveKeyWithValue("IntervalMS", 10000.0))
+		Expect(api.body).NotTo(HaveKey("Interval"))
+	})
+})
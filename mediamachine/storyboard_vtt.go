@@ -0,0 +1,187 @@
+package mediamachine
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StoryboardCue is a tile of a storyboard, shown while the player position is between Start and End
+type StoryboardCue struct {
+	Start time.Duration
+	End   time.Duration
+	URL   string          // Location of the sprite sheet, without the #xywh= fragment
+	Tile  image.Rectangle // Area of the sprite sheet covered by the tile, empty if the cue uses the whole image
+}
+
+// Storyboard is the list of tiles of a WebVTT storyboard
+type Storyboard struct {
+	Cues []StoryboardCue
+}
+
+/*
+ParseStoryboard reads a WebVTT storyboard, where the payload of each cue is the URL of an image, optionally
+followed by a #xywh=x,y,width,height fragment selecting a tile of a sprite sheet.
+
+Cue identifiers, settings and NOTE, STYLE and REGION blocks are ignored.
+*/
+func ParseStoryboard(r io.Reader) (Storyboard, error) {
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	next := func() (string, bool) {
+		if !scanner.Scan() {
+			return "", false
+		}
+		lineNo++
+		return strings.TrimRight(scanner.Text(), "\r"), true
+	}
+
+	header, ok := next()
+	header = strings.TrimPrefix(header, "\ufeff")
+	if !ok || (header != "WEBVTT" && !strings.HasPrefix(header, "WEBVTT ") && !strings.HasPrefix(header, "WEBVTT\t")) {
+		return Storyboard{}, fmt.Errorf("invalid storyboard: missing WEBVTT header")
+	}
+
+	var sb Storyboard
+	var block []string
+	flush := func() error {
+		defer func() { block = block[:0] }()
+		if len(block) == 0 {
+			return nil
+		}
+		timing := 0
+		if !strings.Contains(block[0], "-->") {
+			switch {
+			case strings.HasPrefix(block[0], "NOTE"), block[0] == "STYLE", block[0] == "REGION":
+				return nil
+			}
+			timing = 1 // skip the cue identifier
+		}
+		if timing >= len(block) || !strings.Contains(block[timing], "-->") {
+			return fmt.Errorf("invalid storyboard: missing cue timings before line %d", lineNo)
+		}
+		cue, err := parseStoryboardCue(block[timing], block[timing+1:])
+		if err != nil {
+			return fmt.Errorf("invalid storyboard cue before line %d: %s", lineNo, err)
+		}
+		sb.Cues = append(sb.Cues, cue)
+		return nil
+	}
+
+	for {
+		line, ok := next()
+		if !ok {
+			break
+		}
+		if strings.TrimSpace(line) == "" {
+			if err := flush(); err != nil {
+				return Storyboard{}, err
+			}
+			continue
+		}
+		block = append(block, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return Storyboard{}, err
+	}
+	if err := flush(); err != nil {
+		return Storyboard{}, err
+	}
+	return sb, nil
+}
+
+func parseStoryboardCue(timing string, payload []string) (StoryboardCue, error) {
+	parts := strings.SplitN(timing, "-->", 2)
+	start, err := parseVTTTimestamp(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return StoryboardCue{}, err
+	}
+	end := strings.Fields(parts[1]) // cue settings follow the end timestamp
+	if len(end) == 0 {
+		return StoryboardCue{}, fmt.Errorf("missing end timestamp")
+	}
+	cue := StoryboardCue{Start: start}
+	if cue.End, err = parseVTTTimestamp(end[0]); err != nil {
+		return StoryboardCue{}, err
+	}
+	if cue.End < cue.Start {
+		return StoryboardCue{}, fmt.Errorf("end %s is before start %s", end[0], strings.TrimSpace(parts[0]))
+	}
+	if len(payload) == 0 {
+		return StoryboardCue{}, fmt.Errorf("missing image URL")
+	}
+
+	cue.URL = strings.TrimSpace(payload[0])
+	if i := strings.LastIndex(cue.URL, "#xywh="); i >= 0 {
+		var x, y, w, h int
+		if _, err := fmt.Sscanf(cue.URL[i+len("#xywh="):], "%d,%d,%d,%d", &x, &y, &w, &h); err != nil || w < 0 || h < 0 {
+			return StoryboardCue{}, fmt.Errorf("invalid #xywh fragment: '%s'", cue.URL[i:])
+		}
+		cue.URL, cue.Tile = cue.URL[:i], image.Rect(x, y, x+w, y+h)
+	}
+	return cue, nil
+}
+
+// parseVTTTimestamp parses a WebVTT timestamp, formatted as [hh:]mm:ss.ttt
+func parseVTTTimestamp(s string) (time.Duration, error) {
+	fields := strings.Split(s, ":")
+	if len(fields) < 2 || len(fields) > 3 {
+		return 0, fmt.Errorf("invalid timestamp: '%s'", s)
+	}
+	secParts := strings.Split(fields[len(fields)-1], ".")
+	if len(secParts) != 2 || len(secParts[1]) != 3 {
+		return 0, fmt.Errorf("invalid timestamp: '%s'", s)
+	}
+
+	values := append([]string{}, fields[:len(fields)-1]...)
+	values = append(values, secParts...)
+	units := []time.Duration{time.Hour, time.Minute, time.Second, time.Millisecond}[4-len(values):]
+
+	var total time.Duration
+	for i, v := range values {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp: '%s'", s)
+		}
+		total += time.Duration(n) * units[i]
+	}
+	return total, nil
+}
+
+func formatVTTTimestamp(d time.Duration) string {
+	d = d.Round(time.Millisecond)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d",
+		int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60, d.Milliseconds()%1000)
+}
+
+// WriteTo writes the storyboard as a WebVTT file.
+func (s Storyboard) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for _, cue := range s.Cues {
+		fmt.Fprintf(&b, "\n%s --> %s\n%s", formatVTTTimestamp(cue.Start), formatVTTTimestamp(cue.End), cue.URL)
+		if !cue.Tile.Empty() {
+			fmt.Fprintf(&b, "#xywh=%d,%d,%d,%d", cue.Tile.Min.X, cue.Tile.Min.Y, cue.Tile.Dx(), cue.Tile.Dy())
+		}
+		b.WriteString("\n")
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+/*
+RewriteURLs returns a copy of the storyboard with the URL of each cue replaced by rewrite(URL),
+e.g. to serve the sprite sheets from a CDN.
+*/
+func (s Storyboard) RewriteURLs(rewrite func(string) string) Storyboard {
+	cues := make([]StoryboardCue, len(s.Cues))
+	for i, cue := range s.Cues {
+		cue.URL = rewrite(cue.URL)
+		cues[i] = cue
+	}
+	return Storyboard{Cues: cues}
+}
@@ -0,0 +1,173 @@
+package mediamachine
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/stackrock/mediamachinego/colors"
+)
+
+// SubtitleFormat is the file format of a subtitle input
+type SubtitleFormat = string
+
+// SubtitleMode selects how subtitles are added to the output
+type SubtitleMode = string
+
+const (
+	// SubtitleFormatSRT is a SubRip subtitle file
+	SubtitleFormatSRT SubtitleFormat = "srt"
+	// SubtitleFormatVTT is a WebVTT subtitle file
+	SubtitleFormatVTT SubtitleFormat = "vtt"
+
+	// SubtitleSoft muxes the subtitles as a track of the output that players can turn on and off
+	SubtitleSoft SubtitleMode = "soft"
+	// SubtitleBurnIn draws the subtitles onto the picture, so they are always shown
+	SubtitleBurnIn SubtitleMode = "burn"
+)
+
+// languagePattern matches BCP 47 language tags, such as en, pt-BR or zh-Hant
+var languagePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+// Subtitle is a subtitle or caption file added to the output of a transcode
+type Subtitle struct {
+	// Structured as {http|https|s3|azure|gcp}://{bucket-name}/{prefix-if-any}/{object-name}
+	// Examples: s3://bucket/prefix/captions.en.vtt, https://example.com/files/captions.en.srt
+	URL   string
+	Creds Creds // Provide credentials to S3/Azure/GCP for the URL, can be nil for http(s) urls
+
+	Format   SubtitleFormat // Optional - defaults to the format implied by the extension of URL
+	Mode     SubtitleMode   // Optional - defaults to SubtitleSoft
+	Language string         // BCP 47 language tag, e.g. en or pt-BR, required for SubtitleSoft
+	Label    string         // Optional - name of the track shown by players, e.g. "English (SDH)"
+	Default  bool           // Optional - players show this track by default, only applicable to SubtitleSoft
+	Forced   bool           // Optional - the track only translates foreign dialogue, only applicable to SubtitleSoft
+
+	Style *SubtitleStyle // Optional - only applicable to SubtitleBurnIn
+}
+
+// SubtitleStyle controls the appearance of burned-in subtitles
+type SubtitleStyle struct {
+	FontFamily string            // Optional - one of FontSans (default), FontSerif, FontMono, FontCondensed or an uploaded font
+	FontSize   uint              // Optional - in pixels, defaults to 5% of the output height
+	FontColor  colors.Color      // Optional - defaults to white
+	Stroke     *TextStroke       // Optional - outline drawn around the glyphs
+	Background *TextBackground   // Optional - box drawn behind each line
+	Position   WatermarkPosition // Optional - PositionBottomCenter (default) or PositionTopCenter
+	Margin     uint              // Optional - vertical distance in pixels to the edge of the output
+}
+
+// subtitleContainers lists the containers that can hold soft subtitle tracks
+var subtitleContainers = map[TranscodeContainer]bool{
+	ContainerMP4:  true,
+	ContainerMOV:  true,
+	ContainerMKV:  true,
+	ContainerWebm: true,
+}
+
+func validateSubtitles(container TranscodeContainer, subtitles []Subtitle) error {
+	if len(subtitles) > 0 && container.IsAudioOnly() {
+		return fmt.Errorf("subtitles are not applicable to audio-only container '%s'", container)
+	}
+
+	burnIn, defaults := 0, 0
+	tracks := map[string]bool{}
+	for i, s := range subtitles {
+		if err := validateSubtitle(s); err != nil {
+			return fmt.Errorf("subtitle %d: %s", i, err)
+		}
+		if s.Mode == SubtitleBurnIn {
+			burnIn++
+			continue
+		}
+
+		if !subtitleContainers[container] {
+			return fmt.Errorf("subtitle %d: soft subtitles are not supported by container '%s', use SubtitleBurnIn", i, container)
+		}
+		if container == ContainerWebm && s.format() != SubtitleFormatVTT {
+			return fmt.Errorf("subtitle %d: container '%s' only holds WebVTT soft subtitles, convert the file or use SubtitleBurnIn", i, container)
+		}
+		key := strings.ToLower(s.Language) + "/" + s.Label
+		if tracks[key] {
+			return fmt.Errorf("subtitle %d: duplicate track for language '%s' with label '%s'", i, s.Language, s.Label)
+		}
+		tracks[key] = true
+		if s.Default {
+			defaults++
+		}
+	}
+	if burnIn > 1 {
+		return fmt.Errorf("at most one subtitle can be burned in")
+	}
+	if defaults > 1 {
+		return fmt.Errorf("at most one subtitle track can be Default")
+	}
+	return nil
+}
+
+// format returns the Format of the subtitle file, implied by the extension of URL if not set
+func (s Subtitle) format() SubtitleFormat {
+	if s.Format != "" {
+		return s.Format
+	}
+	return strings.TrimPrefix(outputExtension(s.URL), ".")
+}
+
+func validateSubtitle(s Subtitle) error {
+	if err := validateInput(s.URL, s.Creds); err != nil {
+		return err
+	}
+
+	switch s.format() {
+	case SubtitleFormatSRT, SubtitleFormatVTT:
+	default:
+		if s.Format == "" {
+			return fmt.Errorf("cannot detect the Format from extension '%s', set Format", outputExtension(s.URL))
+		}
+		return fmt.Errorf("unsupported Format: '%s'", s.Format)
+	}
+
+	if s.Language != "" && !languagePattern.MatchString(s.Language) {
+		return fmt.Errorf("invalid Language: '%s'", s.Language)
+	}
+	switch s.Mode {
+	case "", SubtitleSoft:
+		if s.Language == "" {
+			return fmt.Errorf("soft subtitles need a Language")
+		}
+		if s.Style != nil {
+			return fmt.Errorf("a Style is only applicable to burned-in subtitles")
+		}
+	case SubtitleBurnIn:
+		if s.Default || s.Forced {
+			return fmt.Errorf("burned-in subtitles cannot be Default or Forced")
+		}
+		if s.Style != nil {
+			return s.Style.validate()
+		}
+	default:
+		return fmt.Errorf("unsupported Mode: '%s'", s.Mode)
+	}
+	return nil
+}
+
+func (st SubtitleStyle) validate() error {
+	if st.FontFamily != "" && !fontNamePattern.MatchString(st.FontFamily) {
+		return fmt.Errorf("invalid Style FontFamily: '%s'", st.FontFamily)
+	}
+	if st.FontColor != "" && !colors.Valid(st.FontColor) {
+		return fmt.Errorf("invalid Style FontColor: '%s'", st.FontColor)
+	}
+	if st.Stroke != nil && (st.Stroke.Width == 0 || !colors.Valid(st.Stroke.Color)) {
+		return fmt.Errorf("invalid Style Stroke, it must have a Width and a valid Color")
+	}
+	if st.Background != nil && !colors.Valid(st.Background.Color) {
+		return fmt.Errorf("invalid Style Background Color: '%s'", st.Background.Color)
+	}
+	switch st.Position {
+	case "", PositionBottomCenter, PositionTopCenter:
+	default:
+		return fmt.Errorf("unsupported Style Position: '%s'", st.Position)
+	}
+	return nil
+}
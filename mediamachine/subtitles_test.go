@@ -0,0 +1,72 @@
+package mediamachine_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/stackrock/mediamachinego/mediamachine"
+)
+
+var _ = Describe("Subtitles", func() {
+	mm := mediamachine.MediaMachine{}
+	transcode := func(container mediamachine.TranscodeContainer, subtitles ...mediamachine.Subtitle) error {
+		bitrate := mediamachine.Bitrate2Mbps
+		if container.IsAudioOnly() {
+			bitrate = "128"
+		}
+		_, err := mm.Transcode(mediamachine.TranscodeConfig{
+			Container:   container,
+			Encoder:     container.Encoders()[0],
+			BitrateKBPS: bitrate,
+			InputURL:    "https://example.com/input.mp4",
+			OutputURL:   "https://example.com/output",
+			Subtitles:   subtitles,
+		})
+		return err
+	}
+	english := mediamachine.Subtitle{URL: "https://example.com/captions.en.vtt", Language: "en"}
+
+	It("checks that the container supports soft subtitles", func() {
+		Expect(transcode(mediamachine.ContainerMPEGTS, english)).To(MatchError(ContainSubstring("SubtitleBurnIn")))
+		Expect(transcode(mediamachine.ContainerM4A, english)).To(MatchError(ContainSubstring("audio-only")))
+	})
+
+	It("only muxes WebVTT tracks into WebM", func() {
+		restore := mediamachine.UseTransport(&fakeAPI{response: `{"id":"job-1"}`})
+		defer restore()
+
+		srt := mediamachine.Subtitle{URL: "https://example.com/captions.en.srt", Language: "en"}
+		Expect(transcode(mediamachine.ContainerWebm, srt)).To(MatchError(ContainSubstring("only holds WebVTT")))
+		Expect(transcode(mediamachine.ContainerMKV, srt)).To(BeNil())
+		Expect(transcode(mediamachine.ContainerWebm, english)).To(BeNil())
+
+		srt.Mode, srt.Language = mediamachine.SubtitleBurnIn, ""
+		Expect(transcode(mediamachine.ContainerWebm, srt)).To(BeNil())
+	})
+
+	It("rejects invalid tracks", func() {
+		for _, s := range []mediamachine.Subtitle{
+			{URL: "https://example.com/captions.txt", Language: "en"},
+			{URL: "https://example.com/captions.srt"},
+			{URL: "https://example.com/captions.srt", Language: "english!"},
+			{URL: "s3://bucket/captions.srt", Language: "en"},
+			{URL: "https://example.com/captions.srt", Language: "en", Style: &mediamachine.SubtitleStyle{}},
+			{URL: "https://example.com/captions.srt", Mode: mediamachine.SubtitleBurnIn, Default: true},
+			{URL: "https://example.com/captions.srt", Mode: mediamachine.SubtitleBurnIn,
+				Style: &mediamachine.SubtitleStyle{Position: mediamachine.PositionCenter}},
+		} {
+			Expect(transcode(mediamachine.ContainerMP4, s)).NotTo(BeNil(), "%+v", s)
+		}
+	})
+
+	It("rejects conflicting tracks", func() {
+		burn := mediamachine.Subtitle{URL: "https://example.com/captions.srt", Mode: mediamachine.SubtitleBurnIn}
+		Expect(transcode(mediamachine.ContainerMP4, burn, burn)).To(MatchError(ContainSubstring("burned in")))
+		Expect(transcode(mediamachine.ContainerMKV, english, english)).To(MatchError(ContainSubstring("duplicate")))
+
+		french := mediamachine.Subtitle{URL: "https://example.com/captions.fr.vtt", Language: "fr", Default: true}
+		def := english
+		def.Default = true
+		Expect(transcode(mediamachine.ContainerWebm, def, french)).To(MatchError(ContainSubstring("Default")))
+	})
+})
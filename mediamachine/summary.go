@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"time"
 )
 
 // SummaryType represent the possible output type of the summary.
@@ -15,6 +16,10 @@ const (
 	SummaryTypeGif SummaryType = "gif"
 	// SummaryTypeMp4 - represents an output of type `mp4`
 	SummaryTypeMp4 SummaryType = "mp4"
+	// SummaryTypeWebP - represents an output of type `webp`, an animated WebP image
+	SummaryTypeWebP SummaryType = "webp"
+	// SummaryTypeAPNG - represents an output of type `apng`, an animated PNG image
+	SummaryTypeAPNG SummaryType = "apng"
 )
 
 /*
@@ -22,10 +27,15 @@ SummaryConfig configures the request for a video summary.
 The input video location can be specified via the FromUrl or the From method.
 
 By default, the output has the same dimensions as the input video, set Width to desired value to customize.
-Height is automatically calculated according to input aspect ratio.
+Height is automatically calculated according to input aspect ratio unless set explicitly,
+in which case the embedded Picture controls how the input picture is resized into the output frame.
+
+The summary is made of highlights of the input video. TargetDuration, Segments, MinSegmentDuration, Transition and
+SpeedFactor control its length and pacing, the durations are sent to the API in whole milliseconds. The ranges of the input video used by the summary are listed in the
+Segments of the JobOutput, see Job.FetchDetails.
 */
 type SummaryConfig struct {
-	RemoveAudio bool // Only applicable when Type is set to SummaryTypeMp4, ignored otherwise
+	RemoveAudio bool // Optional - only applicable to SummaryMP4
 
 	// Structured as {http|https|s3|azure|gcp}://{bucket-name}/{prefix-if-any}/{object-name}
 	// Examples: s3://bucket/prefix/input.mp4, https://example.com/files/input.mp4
@@ -40,8 +50,21 @@ type SummaryConfig struct {
 	InputCreds  Creds
 	OutputCreds Creds
 
-	Width     uint      // Optional - by default, the output has same width as input video
-	Watermark Watermark // Optional
+	Width      uint        // Optional - by default, the output has same width as input video
+	Height     uint        // Optional - by default, calculated from Width according to input aspect ratio
+	Watermark  Watermark   // Optional
+	Watermarks []Watermark // Optional - additional layers drawn over Watermark, ordered by their ZIndex
+
+	Picture // Optional - see Picture
+
+	TargetDuration     time.Duration     `json:"-"` // Optional - length of the summary, chosen automatically by default
+	Segments           uint              // Optional - number of highlights of the input video used in the summary
+	MinSegmentDuration time.Duration     `json:"-"` // Optional - shortest highlight of the input video used in the summary
+	Transition         SummaryTransition // Optional - defaults to SummaryTransitionCut
+	TransitionDuration time.Duration     `json:"-"` // Optional - only applicable to SummaryTransitionCrossfade
+	SpeedFactor        float64           // Optional - play the highlights faster, from 1 to MaxSummarySpeedFactor
+
+	GIF *GIFOptions // Optional - only applicable to SummaryGIF
 
 	SuccessURL string // Optional - Expect a POST call when job is successfully finished
 	FailureURL string // Optional - Expect a POST call with failure details
@@ -69,16 +92,67 @@ func (m MediaMachine) SummaryMP4(cfg SummaryConfig) (Job, error) {
 	return m.summary(SummaryTypeMp4, cfg)
 }
 
+/*
+SummaryWebP enqueues a request to the MediaMachine backend to asynchronously generate a summary
+uploaded to an s3-compatible-store for the input video.
+
+The output is an animated WebP image, usually much smaller than the same GIF, and is uploaded to the location
+specified in the SummaryConfig.
+Errors if the input configuration is invalid.
+*/
+func (m MediaMachine) SummaryWebP(cfg SummaryConfig) (Job, error) {
+	return m.summary(SummaryTypeWebP, cfg)
+}
+
+/*
+SummaryAPNG enqueues a request to the MediaMachine backend to asynchronously generate a summary
+uploaded to an s3-compatible-store for the input video.
+
+The output is an animated PNG image and is uploaded to the location specified in the SummaryConfig.
+Errors if the input configuration is invalid.
+*/
+func (m MediaMachine) SummaryAPNG(cfg SummaryConfig) (Job, error) {
+	return m.summary(SummaryTypeAPNG, cfg)
+}
+
 func (m MediaMachine) summary(summaryType SummaryType, cfg SummaryConfig) (Job, error) {
 	if err := validateInputOutput(cfg.InputURL, cfg.OutputURL, cfg.InputCreds, cfg.OutputCreds); err != nil {
 		return Job{}, err
 	}
+	if err := cfg.geometry().validate(); err != nil {
+		return Job{}, err
+	}
+	if err := cfg.validatePacing(); err != nil {
+		return Job{}, err
+	}
+	if cfg.RemoveAudio && summaryType != SummaryTypeMp4 {
+		return Job{}, fmt.Errorf("RemoveAudio is only applicable to summaries of type '%s'", SummaryTypeMp4)
+	}
+	if cfg.GIF != nil {
+		if summaryType != SummaryTypeGif {
+			return Job{}, fmt.Errorf("GIF options are only applicable to summaries of type '%s'", SummaryTypeGif)
+		}
+		if err := cfg.GIF.validate(); err != nil {
+			return Job{}, err
+		}
+	}
+	watermarks := watermarkLayers(cfg.Watermark, cfg.Watermarks)
+	if err := validateWatermarks(watermarks, false, Size{Width: cfg.Width, Height: cfg.Height}); err != nil {
+		return Job{}, err
+	}
+	cfg.Watermark, cfg.Watermarks = payloadLayers(cfg.Watermark, cfg.Watermarks)
 	sr := struct {
 		APIKey string
 		SummaryConfig
+		TargetDurationMS     int64
+		MinSegmentDurationMS int64
+		TransitionDurationMS int64
 	}{
-		APIKey:        m.APIKey,
-		SummaryConfig: cfg,
+		APIKey:               m.APIKey,
+		SummaryConfig:        cfg,
+		TargetDurationMS:     cfg.TargetDuration.Milliseconds(),
+		MinSegmentDurationMS: cfg.MinSegmentDuration.Milliseconds(),
+		TransitionDurationMS: cfg.TransitionDuration.Milliseconds(),
 	}
 
 	body, err := json.Marshal(sr)
@@ -89,6 +163,13 @@ func (m MediaMachine) summary(summaryType SummaryType, cfg SummaryConfig) (Job,
 }
 
 func validateInputOutput(inputURL, outputURL string, inputCreds, outputCreds Creds) error {
+	if err := validateInput(inputURL, inputCreds); err != nil {
+		return err
+	}
+	return validateOutput(outputURL, outputCreds)
+}
+
+func validateInput(inputURL string, inputCreds Creds) error {
 	uri, err := url.ParseRequestURI(inputURL)
 	if err != nil {
 		return err
@@ -103,8 +184,11 @@ func validateInputOutput(inputURL, outputURL string, inputCreds, outputCreds Cre
 	default:
 		return fmt.Errorf("inputURL has unsupported scheme: '%s'", uri.Scheme)
 	}
+	return nil
+}
 
-	uri, err = url.ParseRequestURI(outputURL)
+func validateOutput(outputURL string, outputCreds Creds) error {
+	uri, err := url.ParseRequestURI(outputURL)
 	if err != nil {
 		return err
 	}
@@ -118,6 +202,15 @@ func validateInputOutput(inputURL, outputURL string, inputCreds, outputCreds Cre
 	default:
 		return fmt.Errorf("outputURL has unsupported scheme: '%s'", uri)
 	}
-
 	return nil
 }
+
+// OutputLayout computes the dimensions of the summary output for an input of the given size, without submitting a job.
+// See Picture.
+func (cfg SummaryConfig) OutputLayout(input Size) (Layout, error) {
+	return cfg.geometry().layout(input)
+}
+
+func (cfg SummaryConfig) geometry() geometry {
+	return geometry{width: cfg.Width, height: cfg.Height, Picture: cfg.Picture}
+}
@@ -0,0 +1,54 @@
+package mediamachine
+
+import "fmt"
+
+// GIFDither is the dithering algorithm used to reduce the colors of GIF frames to their palette
+type GIFDither = string
+
+const (
+	// GIFDitherNone maps each pixel to the nearest palette color, giving flat areas and banding
+	GIFDitherNone GIFDither = "none"
+	// GIFDitherBayer applies an ordered dither, which compresses better than error diffusion
+	GIFDitherBayer GIFDither = "bayer"
+	// GIFDitherFloydSteinberg applies Floyd-Steinberg error diffusion, the best looking but largest output
+	GIFDitherFloydSteinberg GIFDither = "floyd_steinberg"
+	// GIFDitherSierra applies Sierra Lite error diffusion
+	GIFDitherSierra GIFDither = "sierra"
+
+	// MaxGIFFrameRate is the largest frame rate of a GIF, browsers slow down faster GIFs
+	MaxGIFFrameRate = 50
+	// MinGIFFileSizeBytes is the smallest MaxFileSizeBytes of a GIF
+	MinGIFFileSizeBytes = 10 << 10
+)
+
+/*
+GIFOptions configures the encoding of a GIF summary. They can only be set with SummaryGIF.
+
+When MaxFileSizeBytes is set, MediaMachine lowers the frame rate, width and palette size, in that order, until the
+GIF fits. The job fails if the GIF is still too large at the lowest settings.
+*/
+type GIFOptions struct {
+	FPS              uint      // Optional - frames per second, up to MaxGIFFrameRate, defaults to 10
+	LoopCount        uint      // Optional - number of times the animation is played, 0 (default) loops forever
+	PaletteSize      uint      // Optional - number of colors of the palette, from 2 to 256, defaults to 256
+	Dither           GIFDither // Optional - defaults to GIFDitherBayer
+	MaxFileSizeBytes int64     // Optional - largest size of the GIF file, e.g. for email attachments
+}
+
+func (o GIFOptions) validate() error {
+	if o.FPS > MaxGIFFrameRate {
+		return fmt.Errorf("GIF FPS cannot be greater than %d", MaxGIFFrameRate)
+	}
+	if o.PaletteSize != 0 && (o.PaletteSize < 2 || o.PaletteSize > 256) {
+		return fmt.Errorf("GIF PaletteSize must be between 2 and 256")
+	}
+	switch o.Dither {
+	case "", GIFDitherNone, GIFDitherBayer, GIFDitherFloydSteinberg, GIFDitherSierra:
+	default:
+		return fmt.Errorf("unsupported GIF Dither: '%s'", o.Dither)
+	}
+	if o.MaxFileSizeBytes < 0 || (o.MaxFileSizeBytes != 0 && o.MaxFileSizeBytes < MinGIFFileSizeBytes) {
+		return fmt.Errorf("GIF MaxFileSizeBytes must be at least %d", MinGIFFileSizeBytes)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package mediamachine
+
+import (
+	"fmt"
+	"time"
+)
+
+// SummaryTransition is the transition between two highlights of a summary
+type SummaryTransition = string
+
+const (
+	// SummaryTransitionCut jumps from one highlight to the next
+	SummaryTransitionCut SummaryTransition = "cut"
+	// SummaryTransitionCrossfade blends the end of a highlight into the start of the next
+	SummaryTransitionCrossfade SummaryTransition = "crossfade"
+
+	// MinSummaryDuration is the shortest TargetDuration of a summary
+	MinSummaryDuration = time.Second
+	// MaxSummarySpeedFactor is the largest SpeedFactor of a summary
+	MaxSummarySpeedFactor = 4.0
+	// DefaultTransitionDuration is the length of a crossfade when TransitionDuration is not set
+	DefaultTransitionDuration = 500 * time.Millisecond
+)
+
+// SummarySegment is a highlight of the input video used in a summary, its times are sent by the API in milliseconds
+type SummarySegment struct {
+	SourceStart time.Duration // Start of the highlight in the input video
+	SourceEnd   time.Duration // End of the highlight in the input video
+	OutputStart time.Duration // Position of the highlight in the summary
+}
+
+func (cfg SummaryConfig) validatePacing() error {
+	if cfg.TargetDuration < 0 || cfg.MinSegmentDuration < 0 || cfg.TransitionDuration < 0 {
+		return fmt.Errorf("summary durations cannot be negative")
+	}
+	if cfg.TargetDuration != 0 && cfg.TargetDuration < MinSummaryDuration {
+		return fmt.Errorf("summary TargetDuration must be at least %s", MinSummaryDuration)
+	}
+	if cfg.SpeedFactor != 0 && (cfg.SpeedFactor < 1 || cfg.SpeedFactor > MaxSummarySpeedFactor) {
+		return fmt.Errorf("summary SpeedFactor must be between 1 and %g", MaxSummarySpeedFactor)
+	}
+
+	switch cfg.Transition {
+	case "", SummaryTransitionCut:
+		if cfg.TransitionDuration != 0 {
+			return fmt.Errorf("TransitionDuration is only applicable to transition '%s'", SummaryTransitionCrossfade)
+		}
+	case SummaryTransitionCrossfade:
+		if cfg.MinSegmentDuration != 0 && cfg.transitionDuration() >= cfg.MinSegmentDuration {
+			return fmt.Errorf("summary TransitionDuration must be shorter than MinSegmentDuration")
+		}
+	default:
+		return fmt.Errorf("unsupported summary transition: '%s'", cfg.Transition)
+	}
+
+	if cfg.TargetDuration == 0 {
+		return nil
+	}
+	// the highlights must fit in the summary once sped up
+	speed := cfg.SpeedFactor
+	if speed == 0 {
+		speed = 1
+	}
+	segments := cfg.Segments
+	if segments == 0 {
+		segments = 1
+	}
+	played := time.Duration(float64(time.Duration(segments)*cfg.MinSegmentDuration) / speed)
+	if played > cfg.TargetDuration {
+		return fmt.Errorf("%d segments of at least %s cannot fit in a summary of %s", segments, cfg.MinSegmentDuration,
+			cfg.TargetDuration)
+	}
+	return nil
+}
+
+func (cfg SummaryConfig) transitionDuration() time.Duration {
+	if cfg.TransitionDuration == 0 {
+		return DefaultTransitionDuration
+	}
+	return cfg.TransitionDuration
+}
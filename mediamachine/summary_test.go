@@ -0,0 +1,178 @@
+package mediamachine_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/stackrock/mediamachinego/mediamachine"
+)
+
+var _ = Describe("Summary pacing", func() {
+	mm := mediamachine.MediaMachine{}
+	cfg := func() mediamachine.SummaryConfig {
+		return mediamachine.SummaryConfig{
+			InputURL:  "https://example.com/input.mp4",
+			OutputURL: "https://example.com/summary.mp4",
+		}
+	}
+
+	It("rejects segments that cannot fit in the target duration", func() {
+		c := cfg()
+		c.TargetDuration = 10 * time.Second
+		c.Segments = 5
+		c.MinSegmentDuration = 3 * time.Second
+		_, err := mm.SummaryMP4(c)
+		Expect(err).To(MatchError(ContainSubstring("cannot fit")))
+	})
+
+	It("sends the durations in milliseconds", func() {
+		api := &fakeAPI{response: `{"id":"job-1"}`}
+		defer mediamachine.UseTransport(api)()
+
+		c := cfg()
+		c.TargetDuration = 30 * time.Second
+		c.MinSegmentDuration = 2500 * time.Millisecond
+		c.Transition = mediamachine.SummaryTransitionCrossfade
+		c.TransitionDuration = time.Second
+		_, err := mm.SummaryMP4(c)
+		Expect(err).To(BeNil())
+		Expect(api.body).To(HaveKeyWithValue("TargetDurationMS", 30000.0))
+		Expect(api.body).To(HaveKeyWithValue("MinSegmentDurationMS", 2500.0))
+		Expect(api.body).To(HaveKeyWithValue("TransitionDurationMS", 1000.0))
+		Expect(api.body).NotTo(HaveKey("TargetDuration"))
+	})
+
+	It("decodes the segments of the summary in milliseconds", func() {
+		defer mediamachine.UseTransport(&fakeAPI{response: `{"Status": "done", "Outputs": [
+			{"URL": "s3://bucket/summary.mp4", "Segments": [
+				{"SourceStart": 12000, "SourceEnd": 15500, "OutputStart": 0},
+				{"SourceStart": 40000, "SourceEnd": 42000, "OutputStart": 3500}
+			]}
+		]}`})()
+
+		status, err := mediamachine.Job{ID: "job-1"}.FetchDetails()
+		Expect(err).To(BeNil())
+		Expect(status.Outputs).To(HaveLen(1))
+		Expect(status.Outputs[0].Segments).To(Equal([]mediamachine.SummarySegment{
+			{SourceStart: 12 * time.Second, SourceEnd: 15500 * time.Millisecond},
+			{SourceStart: 40 * time.Second, SourceEnd: 42 * time.Second, OutputStart: 3500 * time.Millisecond},
+		}))
+	})
+
+	It("rejects invalid speed factors and transitions", func() {
+		c := cfg()
+		c.SpeedFactor = 0.5
+		_, err := mm.SummaryMP4(c)
+		Expect(err).NotTo(BeNil())
+
+		c = cfg()
+		c.TransitionDuration = time.Second
+		_, err = mm.SummaryMP4(c)
+		Expect(err).NotTo(BeNil())
+
+		c = cfg()
+		c.Transition = mediamachine.SummaryTransitionCrossfade
+		c.MinSegmentDuration = 400 * time.Millisecond
+		_, err = mm.SummaryMP4(c)
+		Expect(err).To(MatchError(ContainSubstring("TransitionDuration")))
+
+		c = cfg()
+		c.Transition = "wipe"
+		_, err = mm.SummaryMP4(c)
+		Expect(err).NotTo(BeNil())
+	})
+})
+
+var _ = Describe("Summary GIF options", func() {
+	mm := mediamachine.MediaMachine{}
+	cfg := mediamachine.SummaryConfig{
+		InputURL:  "https://example.com/input.mp4",
+		OutputURL: "https://example.com/summary.gif",
+	}
+
+	It("are only applicable to GIF summaries", func() {
+		c := cfg
+		c.GIF = &mediamachine.GIFOptions{FPS: 10}
+		_, err := mm.SummaryMP4(c)
+		Expect(err).To(MatchError(ContainSubstring("only applicable")))
+	})
+
+	It("rejects invalid options", func() {
+		for _, o := range []mediamachine.GIFOptions{
+			{FPS: 60},
+			{PaletteSize: 1},
+			{PaletteSize: 512},
+			{Dither: "atkinson"},
+			{MaxFileSizeBytes: 100},
+		} {
+			o := o
+			c := cfg
+			c.GIF = &o
+			_, err := mm.SummaryGIF(c)
+			Expect(err).NotTo(BeNil(), "%+v", o)
+		}
+	})
+})
+
+var _ = Describe("Animated image summaries", func() {
+	mm := mediamachine.MediaMachine{APIKey: "key"}
+	api := &fakeAPI{response: `{"id":"job-1"}`}
+	var restore func()
+	BeforeEach(func() { restore = mediamachine.UseTransport(api) })
+	AfterEach(func() { restore() })
+
+	It("submits WebP and APNG summaries to their own endpoints", func() {
+		cfg := mediamachine.SummaryConfig{
+			InputURL:  "https://example.com/input.mp4",
+			OutputURL: "https://example.com/summary.webp",
+			Width:     320,
+		}
+		_, err := mm.SummaryWebP(cfg)
+		Expect(err).To(BeNil())
+		Expect(api.path).To(HaveSuffix("/summary/webp"))
+		Expect(api.body).To(HaveKeyWithValue("APIKey", "key"))
+		Expect(api.body).To(HaveKeyWithValue("OutputURL", "https://example.com/summary.webp"))
+		Expect(api.body).To(HaveKeyWithValue("Width", 320.0))
+
+		cfg.OutputURL = "https://example.com/summary.png"
+		_, err = mm.SummaryAPNG(cfg)
+		Expect(err).To(BeNil())
+		Expect(api.path).To(HaveSuffix("/summary/apng"))
+		Expect(api.body).To(HaveKeyWithValue("OutputURL", "https://example.com/summary.png"))
+	})
+
+	It("rejects RemoveAudio outside of MP4 summaries", func() {
+		cfg := mediamachine.SummaryConfig{
+			InputURL:    "https://example.com/input.mp4",
+			OutputURL:   "https://example.com/summary.webp",
+			RemoveAudio: true,
+		}
+		_, err := mm.SummaryWebP(cfg)
+		Expect(err).To(MatchError(ContainSubstring("RemoveAudio")))
+		_, err = mm.SummaryAPNG(cfg)
+		Expect(err).To(MatchError(ContainSubstring("RemoveAudio")))
+		_, err = mm.SummaryGIF(cfg)
+		Expect(err).To(MatchError(ContainSubstring("RemoveAudio")))
+
+		cfg.OutputURL = "https://example.com/summary.mp4"
+		_, err = mm.SummaryMP4(cfg)
+		Expect(err).To(BeNil())
+	})
+
+	It("validates WebP and APNG summaries like GIF summaries", func() {
+		cfg := mediamachine.SummaryConfig{
+			InputURL:  "https://example.com/input.mp4",
+			OutputURL: "https://example.com/summary.webp",
+			GIF:       &mediamachine.GIFOptions{FPS: 10},
+		}
+		_, err := mm.SummaryWebP(cfg)
+		Expect(err).To(MatchError(ContainSubstring("GIF options")))
+
+		cfg.GIF = nil
+		cfg.Rotate = "45"
+		_, err = mm.SummaryAPNG(cfg)
+		Expect(err).To(MatchError(ContainSubstring("Rotate")))
+	})
+})
@@ -3,6 +3,10 @@ package mediamachine
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 )
 
 /*
@@ -12,6 +16,14 @@ The input video location can be specified via the FromUrl or the From method.
 By default, the thumbnail-s3-compatible-store has the same dimensions as the input video, set the Width field to desired value to customize.
 
 If Width is set, Height is calculated automatically to maintain aspect ratio.
+When both Width and Height are set, the embedded Picture controls how the input picture is resized into the output frame.
+
+By default, a single thumbnail is generated from the best frame of the video. Mode selects how several
+thumbnails are generated in the same job. The OutputURL of a job that can produce more than one image must
+contain the {index} or {timestamp} placeholder, see ExpandOutputURL. The produced files are listed in the
+Outputs of the JobStatus.
+
+Format selects the image format of the thumbnails. A warning is logged when it does not match the extension of OutputURL.
 */
 type ThumbnailConfig struct {
 	// Structured as {http|https|s3|azure|gcp}://{bucket-name}/{prefix-if-any}/{object-name}
@@ -27,8 +39,24 @@ type ThumbnailConfig struct {
 	InputCreds  Creds
 	OutputCreds Creds
 
-	Width     uint      // Optional - by default, the thumbnail-s3-compatible-store has same width as input video
-	Watermark Watermark // Optional
+	Width      uint        // Optional - by default, the thumbnail-s3-compatible-store has same width as input video
+	Height     uint        // Optional - by default, calculated from Width according to input aspect ratio
+	Watermark  Watermark   // Optional
+	Watermarks []Watermark // Optional - additional layers drawn over Watermark, ordered by their ZIndex
+
+	Picture // Optional - see Picture
+
+	Format      ThumbnailFormat // Optional - defaults to the format implied by the extension of OutputURL, or JPEG
+	Quality     uint            // Optional - from 1 to 100, for JPEG, WebP and AVIF outputs
+	Progressive bool            // Optional - output a progressive JPEG
+	Lossless    bool            // Optional - lossless compression for WebP and AVIF outputs
+
+	Mode       ThumbnailMode   // Optional - defaults to ThumbnailModeBest
+	Count      uint            // Optional - number of thumbnails for ThumbnailModeBest, defaults to 1
+	Timestamps []time.Duration `json:"-"` // Positions in the input video of the frames for ThumbnailModeTimestamps, sent in whole milliseconds
+	Interval   time.Duration   `json:"-"` // Time between two frames for ThumbnailModeInterval, sent in whole milliseconds
+
+	Selection *ThumbnailSelection // Optional - controls the frames considered by ThumbnailModeBest
 
 	SuccessURL string // Optional - Expect a POST call when job is successfully finished
 	FailureURL string // Optional - Expect a POST call with failure details
@@ -44,13 +72,34 @@ func (m MediaMachine) Thumbnail(cfg ThumbnailConfig) (Job, error) {
 	if err := validateInputOutput(cfg.InputURL, cfg.OutputURL, cfg.InputCreds, cfg.OutputCreds); err != nil {
 		return Job{}, err
 	}
+	if err := cfg.validateFormat("thumbnail"); err != nil {
+		return Job{}, err
+	}
+	if err := cfg.validateMode(); err != nil {
+		return Job{}, err
+	}
+	if err := cfg.validateSelection(); err != nil {
+		return Job{}, err
+	}
+	if err := cfg.geometry().validate(); err != nil {
+		return Job{}, err
+	}
+	watermarks := watermarkLayers(cfg.Watermark, cfg.Watermarks)
+	if err := validateWatermarks(watermarks, true, Size{Width: cfg.Width, Height: cfg.Height}); err != nil {
+		return Job{}, err
+	}
+	cfg.Watermark, cfg.Watermarks = payloadLayers(cfg.Watermark, cfg.Watermarks)
 
 	tr := struct {
 		APIKey string
 		ThumbnailConfig
+		TimestampsMS []int64
+		IntervalMS   int64
 	}{
 		APIKey:          m.APIKey,
 		ThumbnailConfig: cfg,
+		TimestampsMS:    milliseconds(cfg.Timestamps),
+		IntervalMS:      cfg.Interval.Milliseconds(),
 	}
 
 	body, err := json.Marshal(tr)
@@ -59,3 +108,115 @@ func (m MediaMachine) Thumbnail(cfg ThumbnailConfig) (Job, error) {
 	}
 	return m.submit("/thumbnail", bytes.NewBuffer(body))
 }
+
+// ThumbnailMode selects the frames of the input video used for thumbnails
+type ThumbnailMode = string
+
+const (
+	// ThumbnailModeBest picks the Count most representative frames of the video
+	ThumbnailModeBest ThumbnailMode = "best"
+	// ThumbnailModeTimestamps picks the frames at the given Timestamps
+	ThumbnailModeTimestamps ThumbnailMode = "timestamps"
+	// ThumbnailModeInterval picks one frame every Interval, starting at the beginning of the video
+	ThumbnailModeInterval ThumbnailMode = "interval"
+
+	// MaxThumbnails is the largest number of thumbnails that can be requested in ThumbnailModeBest
+	// or ThumbnailModeTimestamps
+	MaxThumbnails = 100
+	// MinThumbnailInterval is the shortest Interval of ThumbnailModeInterval
+	MinThumbnailInterval = time.Second
+)
+
+const (
+	outputIndexPlaceholder     = "{index}"
+	outputTimestampPlaceholder = "{timestamp}"
+)
+
+/*
+ExpandOutputURL returns the location of one of the images of a multi-thumbnail job, as MediaMachine computes it
+from the OutputURL template.
+
+{index} is replaced by the position of the image among the outputs of the job, starting at 0, and {timestamp}
+by the position of its frame in the input video in milliseconds. For example thumb-{index}-{timestamp}.jpg
+gives thumb-2-4500.jpg for the third image, taken at 4.5s.
+*/
+func ExpandOutputURL(template string, index int, timestamp time.Duration) string {
+	return strings.NewReplacer(
+		outputIndexPlaceholder, strconv.Itoa(index),
+		outputTimestampPlaceholder, strconv.FormatInt(timestamp.Milliseconds(), 10),
+	).Replace(template)
+}
+
+// milliseconds converts durations to the whole milliseconds expected by the API
+func milliseconds(ds []time.Duration) []int64 {
+	if ds == nil {
+		return nil
+	}
+	ms := make([]int64, len(ds))
+	for i, d := range ds {
+		ms[i] = d.Milliseconds()
+	}
+	return ms
+}
+
+func (cfg ThumbnailConfig) validateMode() error {
+	multiple := false
+	switch cfg.Mode {
+	case "", ThumbnailModeBest:
+		if len(cfg.Timestamps) > 0 || cfg.Interval != 0 {
+			return fmt.Errorf("Timestamps and Interval are not applicable to thumbnail mode '%s'", ThumbnailModeBest)
+		}
+		if cfg.Count > MaxThumbnails {
+			return fmt.Errorf("thumbnail Count cannot be greater than %d", MaxThumbnails)
+		}
+		multiple = cfg.Count > 1
+	case ThumbnailModeTimestamps:
+		if cfg.Count != 0 || cfg.Interval != 0 {
+			return fmt.Errorf("Count and Interval are not applicable to thumbnail mode '%s'", ThumbnailModeTimestamps)
+		}
+		if len(cfg.Timestamps) == 0 {
+			return fmt.Errorf("thumbnail mode '%s' requires Timestamps", ThumbnailModeTimestamps)
+		}
+		if len(cfg.Timestamps) > MaxThumbnails {
+			return fmt.Errorf("cannot request more than %d thumbnail Timestamps", MaxThumbnails)
+		}
+		seen := map[time.Duration]bool{}
+		for _, ts := range cfg.Timestamps {
+			if ts < 0 {
+				return fmt.Errorf("thumbnail Timestamps cannot be negative: %s", ts)
+			}
+			if seen[ts] {
+				return fmt.Errorf("duplicate thumbnail timestamp: %s", ts)
+			}
+			seen[ts] = true
+		}
+		multiple = len(cfg.Timestamps) > 1
+	case ThumbnailModeInterval:
+		if cfg.Count != 0 || len(cfg.Timestamps) > 0 {
+			return fmt.Errorf("Count and Timestamps are not applicable to thumbnail mode '%s'", ThumbnailModeInterval)
+		}
+		if cfg.Interval < MinThumbnailInterval {
+			return fmt.Errorf("thumbnail Interval must be at least %s", MinThumbnailInterval)
+		}
+		multiple = true
+	default:
+		return fmt.Errorf("unsupported thumbnail mode: '%s'", cfg.Mode)
+	}
+
+	if multiple && !strings.Contains(cfg.OutputURL, outputIndexPlaceholder) &&
+		!strings.Contains(cfg.OutputURL, outputTimestampPlaceholder) {
+		return fmt.Errorf("OutputURL must contain %s or %s when several thumbnails are generated",
+			outputIndexPlaceholder, outputTimestampPlaceholder)
+	}
+	return nil
+}
+
+// OutputLayout computes the dimensions of the thumbnail output for an input of the given size, without submitting a job.
+// See Picture.
+func (cfg ThumbnailConfig) OutputLayout(input Size) (Layout, error) {
+	return cfg.geometry().layout(input)
+}
+
+func (cfg ThumbnailConfig) geometry() geometry {
+	return geometry{width: cfg.Width, height: cfg.Height, Picture: cfg.Picture}
+}
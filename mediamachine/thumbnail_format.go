@@ -0,0 +1,101 @@
+package mediamachine
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ThumbnailFormat is the image format of thumbnails
+type ThumbnailFormat = string
+
+const (
+	// ThumbnailFormatJPEG outputs JPEG images, the default when OutputURL has no known extension
+	ThumbnailFormatJPEG ThumbnailFormat = "jpeg"
+	// ThumbnailFormatPNG outputs PNG images, always lossless
+	ThumbnailFormatPNG ThumbnailFormat = "png"
+	// ThumbnailFormatWebP outputs WebP images
+	ThumbnailFormatWebP ThumbnailFormat = "webp"
+	// ThumbnailFormatAVIF outputs AVIF images
+	ThumbnailFormatAVIF ThumbnailFormat = "avif"
+)
+
+// thumbnailExtensions lists the OutputURL extensions matching each ThumbnailFormat
+var thumbnailExtensions = map[ThumbnailFormat][]string{
+	ThumbnailFormatJPEG: {".jpg", ".jpeg"},
+	ThumbnailFormatPNG:  {".png"},
+	ThumbnailFormatWebP: {".webp"},
+	ThumbnailFormatAVIF: {".avif"},
+}
+
+/*
+OutputFormat returns the image format of the thumbnails: Format when it is set, otherwise the format implied by the
+extension of OutputURL, falling back to ThumbnailFormatJPEG.
+*/
+func (cfg ThumbnailConfig) OutputFormat() ThumbnailFormat {
+	if cfg.Format != "" {
+		return cfg.Format
+	}
+	if format, ok := formatForExtension(outputExtension(cfg.OutputURL)); ok {
+		return format
+	}
+	return ThumbnailFormatJPEG
+}
+
+// validateFormat checks the image format settings of cfg, kind names the output in error messages
+func (cfg ThumbnailConfig) validateFormat(kind string) error {
+	if cfg.Format != "" {
+		if _, ok := thumbnailExtensions[cfg.Format]; !ok {
+			return fmt.Errorf("unsupported %s Format: '%s'", kind, cfg.Format)
+		}
+	}
+	format := cfg.OutputFormat()
+
+	if cfg.Quality > 100 {
+		return fmt.Errorf("%s Quality must be between 1 and 100", kind)
+	}
+	if cfg.Quality != 0 && format == ThumbnailFormatPNG {
+		return fmt.Errorf("%s Quality is not applicable to lossless format '%s'", kind, format)
+	}
+	if cfg.Progressive && format != ThumbnailFormatJPEG {
+		return fmt.Errorf("%s Progressive is only applicable to format '%s'", kind, ThumbnailFormatJPEG)
+	}
+	if cfg.Lossless {
+		if format != ThumbnailFormatWebP && format != ThumbnailFormatAVIF {
+			return fmt.Errorf("%s Lossless is only applicable to formats '%s' and '%s'",
+				kind, ThumbnailFormatWebP, ThumbnailFormatAVIF)
+		}
+		if cfg.Quality != 0 {
+			return fmt.Errorf("%s Quality cannot be set with Lossless", kind)
+		}
+	}
+
+	if ext := outputExtension(cfg.OutputURL); cfg.Format != "" && ext != "" {
+		if implied, ok := formatForExtension(ext); !ok || implied != cfg.Format {
+			log.Printf("mediamachine: %s OutputURL extension '%s' does not match Format '%s'", kind, ext, cfg.Format)
+		}
+	}
+	return nil
+}
+
+// outputExtension returns the lower-case extension of the path of an output URL, or "" if it has none
+func outputExtension(outputURL string) string {
+	u, err := url.Parse(outputURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(path.Ext(u.Path))
+}
+
+func formatForExtension(ext string) (ThumbnailFormat, bool) {
+	for format, exts := range thumbnailExtensions {
+		for _, e := range exts {
+			if e == ext {
+				return format, true
+			}
+		}
+	}
+	return "", false
+}
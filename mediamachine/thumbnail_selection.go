@@ -0,0 +1,78 @@
+package mediamachine
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+/*
+ThumbnailSelection controls how ThumbnailModeBest picks the frames of the input video.
+
+The frames are searched between SearchStart and SearchEnd, without the first SkipStart and the last SkipEnd of the
+video. Frames matching one of the Reject fields are never picked, and frames showing faces or text rank higher
+when PreferFaces or PreferText are set.
+
+The chosen timestamps and the scores of the frames are listed in the Outputs of the JobStatus, so a nearby frame
+can be requested afterwards with ThumbnailModeTimestamps. The durations are sent to the API in whole milliseconds.
+*/
+type ThumbnailSelection struct {
+	SearchStart time.Duration `json:"-"` // Optional - start of the part of the video to search, defaults to the beginning
+	SearchEnd   time.Duration `json:"-"` // Optional - end of the part of the video to search, defaults to the end
+	SkipStart   time.Duration `json:"-"` // Optional - exclude the first SkipStart of the video, e.g. to skip an intro
+	SkipEnd     time.Duration `json:"-"` // Optional - exclude the last SkipEnd of the video, e.g. to skip the credits
+
+	PreferFaces bool // Optional - rank frames showing faces higher
+	PreferText  bool // Optional - rank frames showing text higher
+
+	RejectBlack  bool // Optional - never pick black or nearly uniform frames
+	RejectBlurry bool // Optional - never pick blurry frames, e.g. during fast motion
+	RejectFades  bool // Optional - never pick frames during fades and transitions
+}
+
+// MarshalJSON sends the durations of s in milliseconds, as expected by the API.
+func (s ThumbnailSelection) MarshalJSON() ([]byte, error) {
+	type thumbnailSelection ThumbnailSelection
+	return json.Marshal(struct {
+		thumbnailSelection
+		SearchStartMS int64
+		SearchEndMS   int64
+		SkipStartMS   int64
+		SkipEndMS     int64
+	}{
+		thumbnailSelection: thumbnailSelection(s),
+		SearchStartMS:      s.SearchStart.Milliseconds(),
+		SearchEndMS:        s.SearchEnd.Milliseconds(),
+		SkipStartMS:        s.SkipStart.Milliseconds(),
+		SkipEndMS:          s.SkipEnd.Milliseconds(),
+	})
+}
+
+// FrameScores are the quality scores of a frame picked for a thumbnail, from 0 (worst) to 1 (best)
+type FrameScores struct {
+	Overall    float64 // Combined score used to rank the frames
+	Sharpness  float64
+	Brightness float64 // 0 for black frames, 1 for well exposed frames
+	Faces      float64 // Confidence that the frame shows faces
+	Text       float64 // Confidence that the frame shows text
+}
+
+func (cfg ThumbnailConfig) validateSelection() error {
+	sel := cfg.Selection
+	if sel == nil {
+		return nil
+	}
+	if cfg.Mode != "" && cfg.Mode != ThumbnailModeBest {
+		return fmt.Errorf("thumbnail Selection is only applicable to thumbnail mode '%s'", ThumbnailModeBest)
+	}
+	if sel.SearchStart < 0 || sel.SearchEnd < 0 || sel.SkipStart < 0 || sel.SkipEnd < 0 {
+		return fmt.Errorf("thumbnail Selection durations cannot be negative")
+	}
+	if sel.SearchEnd != 0 && sel.SearchEnd <= sel.SearchStart {
+		return fmt.Errorf("thumbnail Selection SearchEnd must be after SearchStart")
+	}
+	if sel.SearchEnd != 0 && sel.SkipStart >= sel.SearchEnd-sel.SkipEnd {
+		return fmt.Errorf("thumbnail Selection SkipStart must be before SearchEnd minus SkipEnd")
+	}
+	return nil
+}
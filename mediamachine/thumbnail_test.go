@@ -0,0 +1,184 @@
+package mediamachine_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/stackrock/mediamachinego/mediamachine"
+)
+
+var _ = Describe("Multiple thumbnails", func() {
+	mm := mediamachine.MediaMachine{}
+	cfg := func(output string) mediamachine.ThumbnailConfig {
+		return mediamachine.ThumbnailConfig{
+			InputURL:  "https://example.com/input.mp4",
+			OutputURL: output,
+		}
+	}
+
+	It("expands the OutputURL template", func() {
+		url := mediamachine.ExpandOutputURL("s3://bucket/thumb-{index}-{timestamp}.jpg", 2, 4500*time.Millisecond)
+		Expect(url).To(Equal("s3://bucket/thumb-2-4500.jpg"))
+	})
+
+	It("lists the produced files with their timestamps", func() {
+		restore := mediamachine.UseTransport(&fakeAPI{response: `{"Status": "done", "Outputs": [
+			{"URL": "s3://bucket/thumb-0-0.jpg", "Index": 0, "Timestamp": 0},
+			{"URL": "s3://bucket/thumb-1-4500.jpg", "Index": 1, "Timestamp": 4500}
+		]}`})
+		defer restore()
+
+		status, err := mediamachine.Job{ID: "job-1"}.FetchDetails()
+		Expect(err).To(BeNil())
+		Expect(status.Outputs).To(HaveLen(2))
+		for _, out := range status.Outputs {
+			Expect(out.URL).To(Equal(mediamachine.ExpandOutputURL("s3://bucket/thumb-{index}-{timestamp}.jpg", out.Index, out.Timestamp)))
+		}
+		Expect(status.Outputs[1].Timestamp).To(Equal(4500 * time.Millisecond))
+	})
+
+	It("sends timestamps and intervals in milliseconds", func() {
+		api := &fakeAPI{response: `{"id":"job-1"}`}
+		defer mediamachine.UseTransport(api)()
+
+		c := cfg("https://example.com/thumb-{timestamp}.jpg")
+		c.Mode = mediamachine.ThumbnailModeTimestamps
+		c.Timestamps = []time.Duration{1500 * time.Millisecond, 3 * time.Second}
+		_, err := mm.Thumbnail(c)
+		Expect(err).To(BeNil())
+		Expect(api.body).To(HaveKeyWithValue("TimestampsMS", []interface{}{1500.0, 3000.0}))
+		Expect(api.body).NotTo(HaveKey("Timestamps"))
+
+		c = cfg("https://example.com/thumb-{timestamp}.jpg")
+		c.Mode = mediamachine.ThumbnailModeInterval
+		c.Interval = 5 * time.Second
+		_, err = mm.Thumbnail(c)
+		Expect(err).To(BeNil())
+		Expect(api.body).To(HaveKeyWithValue("IntervalMS", 5000.0))
+		Expect(api.body).NotTo(HaveKey("Interval"))
+	})
+
+	It("requires a placeholder in OutputURL when several images are produced", func() {
+		c := cfg("https://example.com/thumb.jpg")
+		c.Mode = mediamachine.ThumbnailModeInterval
+		c.Interval = 5 * time.Second
+		_, err := mm.Thumbnail(c)
+		Expect(err).To(MatchError(ContainSubstring("{index}")))
+
+		c = cfg("https://example.com/thumb.jpg")
+		c.Count = 3
+		_, err = mm.Thumbnail(c)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("rejects settings of other modes", func() {
+		c := cfg("https://example.com/thumb-{index}.jpg")
+		c.Mode = mediamachine.ThumbnailModeTimestamps
+		c.Timestamps = []time.Duration{time.Second}
+		c.Interval = time.Second
+		_, err := mm.Thumbnail(c)
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("rejects invalid timestamps and intervals", func() {
+		c := cfg("https://example.com/thumb-{timestamp}.jpg")
+		c.Mode = mediamachine.ThumbnailModeTimestamps
+		c.Timestamps = []time.Duration{time.Second, time.Second}
+		_, err := mm.Thumbnail(c)
+		Expect(err).To(MatchError(ContainSubstring("duplicate")))
+
+		c.Timestamps = []time.Duration{-time.Second}
+		_, err = mm.Thumbnail(c)
+		Expect(err).NotTo(BeNil())
+
+		c = cfg("https://example.com/thumb-{timestamp}.jpg")
+		c.Mode = mediamachine.ThumbnailModeInterval
+		c.Interval = 100 * time.Millisecond
+		_, err = mm.Thumbnail(c)
+		Expect(err).NotTo(BeNil())
+	})
+})
+
+var _ = Describe("Thumbnail format", func() {
+	mm := mediamachine.MediaMachine{}
+
+	It("infers the format from the OutputURL extension", func() {
+		Expect(mediamachine.ThumbnailConfig{OutputURL: "s3://bucket/thumb.WEBP"}.OutputFormat()).
+			To(Equal(mediamachine.ThumbnailFormatWebP))
+		Expect(mediamachine.ThumbnailConfig{OutputURL: "s3://bucket/thumb"}.OutputFormat()).
+			To(Equal(mediamachine.ThumbnailFormatJPEG))
+		Expect(mediamachine.ThumbnailConfig{OutputURL: "s3://bucket/thumb.jpg", Format: mediamachine.ThumbnailFormatAVIF}.OutputFormat()).
+			To(Equal(mediamachine.ThumbnailFormatAVIF))
+	})
+
+	It("rejects options that do not apply to the format", func() {
+		for _, c := range []mediamachine.ThumbnailConfig{
+			{OutputURL: "https://example.com/thumb.png", Quality: 80},
+			{OutputURL: "https://example.com/thumb.webp", Progressive: true},
+			{OutputURL: "https://example.com/thumb.jpg", Lossless: true},
+			{OutputURL: "https://example.com/thumb.avif", Lossless: true, Quality: 50},
+			{OutputURL: "https://example.com/thumb.jpg", Quality: 101},
+			{OutputURL: "https://example.com/thumb", Format: "bmp"},
+		} {
+			c.InputURL = "https://example.com/input.mp4"
+			_, err := mm.Thumbnail(c)
+			Expect(err).NotTo(BeNil(), "%+v", c)
+		}
+	})
+})
+
+var _ = Describe("Thumbnail selection", func() {
+	mm := mediamachine.MediaMachine{}
+
+	It("rejects invalid search windows", func() {
+		for _, sel := range []mediamachine.ThumbnailSelection{
+			{SearchStart: 10 * time.Second, SearchEnd: 5 * time.Second},
+			{SkipStart: 30 * time.Second, SearchEnd: 20 * time.Second},
+			{SkipStart: 10 * time.Second, SkipEnd: 10 * time.Second, SearchEnd: 20 * time.Second},
+			{SkipEnd: -time.Second},
+		} {
+			sel := sel
+			_, err := mm.Thumbnail(mediamachine.ThumbnailConfig{
+				InputURL:  "https://example.com/input.mp4",
+				OutputURL: "https://example.com/thumb.jpg",
+				Selection: &sel,
+			})
+			Expect(err).NotTo(BeNil(), "%+v", sel)
+		}
+	})
+
+	It("sends the search window in milliseconds", func() {
+		api := &fakeAPI{response: `{"id":"job-1"}`}
+		defer mediamachine.UseTransport(api)()
+
+		_, err := mm.Thumbnail(mediamachine.ThumbnailConfig{
+			InputURL:  "https://example.com/input.mp4",
+			OutputURL: "https://example.com/thumb.jpg",
+			Selection: &mediamachine.ThumbnailSelection{
+				SearchEnd:   time.Minute,
+				SkipStart:   1500 * time.Millisecond,
+				SkipEnd:     10 * time.Second,
+				RejectBlack: true,
+			},
+		})
+		Expect(err).To(BeNil())
+		Expect(api.body["Selection"]).To(Equal(map[string]interface{}{
+			"SearchStartMS": 0.0, "SearchEndMS": 60000.0, "SkipStartMS": 1500.0, "SkipEndMS": 10000.0,
+			"PreferFaces": false, "PreferText": false,
+			"RejectBlack": true, "RejectBlurry": false, "RejectFades": false,
+		}))
+	})
+
+	It("is only applicable to the best frames mode", func() {
+		_, err := mm.Thumbnail(mediamachine.ThumbnailConfig{
+			InputURL:   "https://example.com/input.mp4",
+			OutputURL:  "https://example.com/thumb.jpg",
+			Mode:       mediamachine.ThumbnailModeTimestamps,
+			Timestamps: []time.Duration{time.Second},
+			Selection:  &mediamachine.ThumbnailSelection{RejectBlack: true},
+		})
+		Expect(err).To(MatchError(ContainSubstring("Selection")))
+	})
+})
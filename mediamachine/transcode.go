@@ -3,17 +3,19 @@ package mediamachine
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"time"
 )
 
 // TranscodeEncoder is the type representing the type of encoder that can be used for
 // a transcode job.
-type TranscodeEncoder = string
+type TranscodeEncoder string
 
 //TranscodeBitrate is the type representing the bitrate to be used for a transcode job.
 type TranscodeBitrate = string
 
 // TranscodeContainer is the type representing the container of the output video.
-type TranscodeContainer = string
+type TranscodeContainer string
 
 // TranscodeVideoSize is the type representing the output video size.
 type TranscodeVideoSize = string
@@ -27,6 +29,21 @@ const (
 	EncoderVp8 TranscodeEncoder = "vp8"
 	// EncoderVp9 is the configuration for a `vp9` encoder.
 	EncoderVp9 TranscodeEncoder = "vp9"
+	// EncoderAV1 is the configuration for an `av1` encoder.
+	EncoderAV1 TranscodeEncoder = "av1"
+	// EncoderProRes is the configuration for a `prores` encoder, intended for editorial workflows.
+	EncoderProRes TranscodeEncoder = "prores"
+
+	// EncoderAAC is the configuration for an `aac` audio encoder, producing an audio-only output.
+	EncoderAAC TranscodeEncoder = "aac"
+	// EncoderMP3 is the configuration for a `mp3` audio encoder, producing an audio-only output.
+	EncoderMP3 TranscodeEncoder = "mp3"
+	// EncoderVorbis is the configuration for a `vorbis` audio encoder, producing an audio-only output.
+	EncoderVorbis TranscodeEncoder = "vorbis"
+	// EncoderOpus is the configuration for an `opus` audio encoder, producing an audio-only output.
+	EncoderOpus TranscodeEncoder = "opus"
+	// EncoderPCM is the configuration for an uncompressed `pcm` audio encoder, producing an audio-only output.
+	EncoderPCM TranscodeEncoder = "pcm"
 
 	// Bitrate4Mbps is the configuration for a `4000 kbps` bitrate.
 	Bitrate4Mbps TranscodeBitrate = "4000"
@@ -39,6 +56,21 @@ const (
 	ContainerMP4 TranscodeContainer = "mp4"
 	// ContainerWebm is the configuration for a `webm` video container.
 	ContainerWebm TranscodeContainer = "webm"
+	// ContainerMKV is the configuration for a `mkv` (matroska) video container.
+	ContainerMKV TranscodeContainer = "mkv"
+	// ContainerMOV is the configuration for a `mov` (quicktime) video container.
+	ContainerMOV TranscodeContainer = "mov"
+	// ContainerMPEGTS is the configuration for a `ts` (MPEG transport stream) video container.
+	ContainerMPEGTS TranscodeContainer = "ts"
+
+	// ContainerM4A is the configuration for a `m4a` audio-only container.
+	ContainerM4A TranscodeContainer = "m4a"
+	// ContainerMP3 is the configuration for a `mp3` audio-only container.
+	ContainerMP3 TranscodeContainer = "mp3"
+	// ContainerOgg is the configuration for an `ogg` audio-only container.
+	ContainerOgg TranscodeContainer = "ogg"
+	// ContainerWAV is the configuration for a `wav` audio-only container.
+	ContainerWAV TranscodeContainer = "wav"
 )
 
 /*
@@ -48,11 +80,16 @@ The input video location can be specified via the FromUrl or the From method.
 By default, the output has the same dimensions as the input video.
 Set Width to desired value to customize.
 Height can also be specified, however it is automatically calculated according to input aspect ratio if not specified.
+When both Width and Height are set, the embedded Picture controls how the input picture is resized into the output frame.
 */
 type TranscodeConfig struct {
-	Container   TranscodeContainer // required
-	Encoder     TranscodeEncoder   // required
-	BitrateKBPS TranscodeBitrate   // required
+	// Optional - name of a registered Preset providing defaults for the output settings below.
+	// Fields set on the TranscodeConfig take precedence over the preset.
+	Preset string `json:"-"`
+
+	Container   TranscodeContainer // required, unless provided by Preset
+	Encoder     TranscodeEncoder   // required, unless provided by Preset
+	BitrateKBPS TranscodeBitrate   // required, unless provided by Preset
 
 	// Structured as {http|https|s3|azure|gcp}://{bucket-name}/{prefix-if-any}/{object-name}
 	// Examples: s3://bucket/prefix/input.mp4, https://example.com/files/input.mp4
@@ -70,6 +107,24 @@ type TranscodeConfig struct {
 	Height uint // Optional - by default, the output has same height as input video
 	Width  uint // Optional - by default, the output has same width as input video
 
+	Picture // Optional - see Picture
+
+	Watermark  Watermark   // Optional - use the Timing field of the Watermark to show it for part of the video only
+	Watermarks []Watermark // Optional - additional layers drawn over Watermark, ordered by their ZIndex
+
+	Subtitles []Subtitle // Optional - soft subtitle tracks, in any number of languages, and at most one burned-in subtitle
+
+	// Frame rate conversion, set at most one of FrameRate and MaxFrameRate.
+	FrameRate    float64 // Optional - output is converted to exactly this many frames per second
+	MaxFrameRate float64 // Optional - output keeps the input frame rate, capped to this many frames per second
+
+	// Keyframe placement, set at most one of KeyframeInterval and KeyframeIntervalFrames.
+	// A fixed interval is needed to segment the output cleanly, e.g. for HLS.
+	KeyframeInterval       time.Duration `json:"-"` // Optional - time between keyframes, sent to the API in whole milliseconds
+	KeyframeIntervalFrames uint          // Optional - number of frames between keyframes
+	ClosedGOP              bool          // Optional - frames never reference frames of a previous GOP (h264/h265 only)
+	DisableSceneCut        bool          // Optional - do not insert extra keyframes on scene changes (h264/h265 only)
+
 	SuccessURL string // Optional - Expect a POST call when job is successfully finished
 	FailureURL string // Optional - Expect a POST call with failure details
 }
@@ -81,16 +136,55 @@ The output video is uploaded to the location specified in the TranscodeConfig.
 Errors if the input configuration is invalid.
 */
 func (m MediaMachine) Transcode(cfg TranscodeConfig) (Job, error) {
+	cfg, err := m.applyPreset(cfg)
+	if err != nil {
+		return Job{}, err
+	}
 	if err := validateInputOutput(cfg.InputURL, cfg.OutputURL, cfg.InputCreds, cfg.OutputCreds); err != nil {
 		return Job{}, err
 	}
+	if err := validateCodecs(cfg.Container, cfg.Encoder); err != nil {
+		return Job{}, err
+	}
+	if cfg.Container.IsAudioOnly() {
+		// audio-only outputs have the same limits as ExtractAudio
+		bitrate, err := parseBitrate(cfg.BitrateKBPS)
+		if err != nil {
+			return Job{}, err
+		}
+		if err := validateAudioOutput(cfg.Encoder, bitrate, 0, 0); err != nil {
+			return Job{}, err
+		}
+	}
+	if err := cfg.geometry().validate(); err != nil {
+		return Job{}, err
+	}
+	if cfg.Container.IsAudioOnly() && cfg.geometry() != (geometry{}) {
+		return Job{}, fmt.Errorf("output size and picture options are not applicable to audio-only container '%s'", cfg.Container)
+	}
+	if err := validateFrameControls(cfg); err != nil {
+		return Job{}, err
+	}
+	if err := validateSubtitles(cfg.Container, cfg.Subtitles); err != nil {
+		return Job{}, err
+	}
+	watermarks := watermarkLayers(cfg.Watermark, cfg.Watermarks)
+	if len(watermarks) > 0 && cfg.Container.IsAudioOnly() {
+		return Job{}, fmt.Errorf("watermark is not applicable to audio-only container '%s'", cfg.Container)
+	}
+	if err := validateWatermarks(watermarks, false, Size{Width: cfg.Width, Height: cfg.Height}); err != nil {
+		return Job{}, err
+	}
+	cfg.Watermark, cfg.Watermarks = payloadLayers(cfg.Watermark, cfg.Watermarks)
 
 	tr := struct {
 		APIKey string
 		TranscodeConfig
+		KeyframeIntervalMS int64
 	}{
-		APIKey:          m.APIKey,
-		TranscodeConfig: cfg,
+		APIKey:             m.APIKey,
+		TranscodeConfig:    cfg,
+		KeyframeIntervalMS: cfg.KeyframeInterval.Milliseconds(),
 	}
 
 	body, err := json.Marshal(tr)
@@ -99,3 +193,78 @@ func (m MediaMachine) Transcode(cfg TranscodeConfig) (Job, error) {
 	}
 	return m.submit("/transcode", bytes.NewBuffer(body))
 }
+
+// maxFrameRate is the highest frame rate accepted for FrameRate and MaxFrameRate
+const maxFrameRate = 240
+
+func validateFrameControls(cfg TranscodeConfig) error {
+	if cfg.FrameRate != 0 && cfg.MaxFrameRate != 0 {
+		return fmt.Errorf("only one of FrameRate and MaxFrameRate can be set")
+	}
+	if cfg.FrameRate < 0 || cfg.FrameRate > maxFrameRate {
+		return fmt.Errorf("FrameRate must be between 0 and %d, got %g", maxFrameRate, cfg.FrameRate)
+	}
+	if cfg.MaxFrameRate < 0 || cfg.MaxFrameRate > maxFrameRate {
+		return fmt.Errorf("MaxFrameRate must be between 0 and %d, got %g", maxFrameRate, cfg.MaxFrameRate)
+	}
+
+	if cfg.KeyframeInterval != 0 && cfg.KeyframeIntervalFrames != 0 {
+		return fmt.Errorf("only one of KeyframeInterval and KeyframeIntervalFrames can be set")
+	}
+	if cfg.KeyframeInterval < 0 {
+		return fmt.Errorf("KeyframeInterval cannot be negative")
+	}
+	if cfg.KeyframeInterval != 0 && cfg.KeyframeInterval < time.Millisecond*100 {
+		return fmt.Errorf("KeyframeInterval must be at least 100ms, got %s", cfg.KeyframeInterval)
+	}
+	if cfg.KeyframeInterval%time.Millisecond != 0 {
+		return fmt.Errorf("KeyframeInterval must be a whole number of milliseconds, got %s", cfg.KeyframeInterval)
+	}
+
+	keyframes := cfg.KeyframeInterval != 0 || cfg.KeyframeIntervalFrames != 0 || cfg.ClosedGOP || cfg.DisableSceneCut
+	switch cfg.Encoder {
+	case EncoderH264, EncoderH265:
+		// supports all frame controls
+	case EncoderVp8, EncoderVp9, EncoderAV1:
+		if cfg.ClosedGOP {
+			return fmt.Errorf("ClosedGOP is not supported by encoder '%s', keyframes always start a new group", cfg.Encoder)
+		}
+		if cfg.DisableSceneCut {
+			return fmt.Errorf("DisableSceneCut is not supported by encoder '%s'", cfg.Encoder)
+		}
+	case EncoderProRes:
+		if keyframes {
+			return fmt.Errorf("keyframe controls are not applicable to encoder '%s', every frame is a keyframe", cfg.Encoder)
+		}
+	default:
+		if cfg.Encoder.IsAudio() && (keyframes || cfg.FrameRate != 0 || cfg.MaxFrameRate != 0) {
+			return fmt.Errorf("frame rate and keyframe controls are not applicable to audio encoder '%s'", cfg.Encoder)
+		}
+	}
+	return nil
+}
+
+func (m MediaMachine) applyPreset(cfg TranscodeConfig) (TranscodeConfig, error) {
+	if cfg.Preset == "" {
+		return cfg, nil
+	}
+	registry := m.Presets
+	if registry == nil {
+		registry = DefaultPresets
+	}
+	p, ok := registry.Lookup(cfg.Preset)
+	if !ok {
+		return cfg, fmt.Errorf("unknown preset: '%s'", cfg.Preset)
+	}
+	return p.Apply(cfg), nil
+}
+
+// OutputLayout computes the dimensions of the output video for an input of the given size, without submitting a job.
+// See Picture.
+func (cfg TranscodeConfig) OutputLayout(input Size) (Layout, error) {
+	return cfg.geometry().layout(input)
+}
+
+func (cfg TranscodeConfig) geometry() geometry {
+	return geometry{width: cfg.Width, height: cfg.Height, Picture: cfg.Picture}
+}
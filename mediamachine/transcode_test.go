@@ -0,0 +1,110 @@
+package mediamachine_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/stackrock/mediamachinego/mediamachine"
+)
+
+// fakeAPI answers every request with response and records the path and body of the last request
+type fakeAPI struct {
+	response string
+	path     string
+	body     map[string]interface{}
+}
+
+func (f *fakeAPI) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.path = req.URL.Path
+	f.body = nil
+	if req.Body != nil {
+		data, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &f.body); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewBufferString(f.response)),
+		Request:    req,
+	}, nil
+}
+
+var _ = Describe("Transcode frame controls", func() {
+	mm := mediamachine.MediaMachine{APIKey: "key"}
+	cfg := func() mediamachine.TranscodeConfig {
+		return mediamachine.TranscodeConfig{
+			Container:   mediamachine.ContainerMP4,
+			Encoder:     mediamachine.EncoderH264,
+			BitrateKBPS: mediamachine.Bitrate2Mbps,
+			InputURL:    "https://example.com/input.mp4",
+			OutputURL:   "https://example.com/output.mp4",
+		}
+	}
+
+	// requests that pass validation reach the fake API and succeed
+	api := &fakeAPI{response: `{"id":"job-1"}`}
+	var restore func()
+	BeforeEach(func() { restore = mediamachine.UseTransport(api) })
+	AfterEach(func() { restore() })
+
+	It("sends the keyframe interval in milliseconds", func() {
+		c := cfg()
+		c.KeyframeInterval = 2 * time.Second
+		_, err := mm.Transcode(c)
+		Expect(err).To(BeNil())
+		Expect(api.body).To(HaveKeyWithValue("KeyframeIntervalMS", BeNumerically("==", 2000)))
+		Expect(api.body).NotTo(HaveKey("KeyframeInterval"))
+	})
+
+	It("allows only one frame rate and one keyframe setting", func() {
+		c := cfg()
+		c.FrameRate, c.MaxFrameRate = 30, 60
+		_, err := mm.Transcode(c)
+		Expect(err).To(MatchError(ContainSubstring("only one of FrameRate and MaxFrameRate")))
+
+		c = cfg()
+		c.KeyframeInterval, c.KeyframeIntervalFrames = 2*time.Second, 60
+		_, err = mm.Transcode(c)
+		Expect(err).To(MatchError(ContainSubstring("only one of KeyframeInterval and KeyframeIntervalFrames")))
+	})
+
+	It("rejects out of range values", func() {
+		for _, c := range []mediamachine.TranscodeConfig{
+			{FrameRate: -1},
+			{MaxFrameRate: 500},
+			{KeyframeInterval: -time.Second},
+			{KeyframeInterval: 50 * time.Millisecond},
+			{KeyframeInterval: 150*time.Millisecond + 500*time.Microsecond},
+		} {
+			base := cfg()
+			base.FrameRate, base.MaxFrameRate, base.KeyframeInterval = c.FrameRate, c.MaxFrameRate, c.KeyframeInterval
+			_, err := mm.Transcode(base)
+			Expect(err).NotTo(BeNil(), "%+v", c)
+		}
+	})
+
+	It("rejects controls the encoder does not support", func() {
+		c := cfg()
+		c.Container, c.Encoder, c.ClosedGOP = mediamachine.ContainerWebm, mediamachine.EncoderVp9, true
+		_, err := mm.Transcode(c)
+		Expect(err).To(MatchError(ContainSubstring("ClosedGOP")))
+
+		c = cfg()
+		c.Container, c.Encoder, c.KeyframeIntervalFrames = mediamachine.ContainerMOV, mediamachine.EncoderProRes, 30
+		_, err = mm.Transcode(c)
+		Expect(err).To(MatchError(ContainSubstring("every frame is a keyframe")))
+	})
+})
@@ -1,6 +1,16 @@
 package mediamachine
 
-// WatermarkPosition are references to named, pre-defined watermark locations
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/stackrock/mediamachinego/colors"
+)
+
+// WatermarkPosition are references to named, pre-defined watermark locations.
+// A Watermark without a Position is placed in the bottom right corner.
 type WatermarkPosition = string
 
 const (
@@ -12,34 +22,202 @@ const (
 	PositionBottomLeft WatermarkPosition = "bottomLeft"
 	// PositionBottomRight places a watermark in the bottom right corner of the output
 	PositionBottomRight WatermarkPosition = "bottomRight"
+	// PositionCenter places a watermark in the center of the output
+	PositionCenter WatermarkPosition = "center"
+	// PositionTopCenter places a watermark horizontally centered at the top of the output
+	PositionTopCenter WatermarkPosition = "topCenter"
+	// PositionBottomCenter places a watermark horizontally centered at the bottom of the output
+	PositionBottomCenter WatermarkPosition = "bottomCenter"
+	// PositionTile repeats a watermark over the whole output, e.g. for anti-piracy overlays
+	PositionTile WatermarkPosition = "tile"
 )
 
-// WatermarkText can be used for a simple text Watermark overlaid on the output
+/*
+WatermarkOffset moves a Watermark away from the point given by its WatermarkPosition.
+
+With PositionTile, X and Y shift the whole pattern and Margin is the gap between repeated tiles.
+*/
+type WatermarkOffset struct {
+	X       float64 // Horizontal offset, positive values move the Watermark right
+	Y       float64 // Vertical offset, positive values move the Watermark down
+	Percent bool    // X and Y are percentages of the output width and height instead of pixels
+	Margin  uint    // Optional - distance in pixels from the anchored edges, defaults to 10
+}
+
+/*
+WatermarkText can be used for a text Watermark overlaid on the output
+
+Text can span multiple lines separated by '\n', aligned according to Align.
+Right-to-left scripts are detected automatically, set Direction to override the detection.
+
+Text can also be a template using text/template syntax, rendered by MediaMachine for every frame.
+See TextTemplateData for the available variables, e.g. "{{.JobID}} - {{.Timecode}} - {{.Meta.viewerEmail}}".
+*/
 type WatermarkText struct {
-	Text      string            // The text to display as the Watermark
-	FontSize  uint              // Optional - defaults to 10
-	FontColor string            // Optional - defaults to black
-	Opacity   float32           // Opacity of Watermark between 0 and 1 inclusive
-	Position  WatermarkPosition // Where the Watermark should be placed. See WatermarkPosition
+	Text       string            // The text to display as the Watermark, can be a template
+	Meta       map[string]string // Optional - values available as {{.Meta.key}} in a templated Text
+	FontSize   uint              // Optional - defaults to 10
+	FontColor  colors.Color      // Optional - defaults to black
+	FontFamily string            // Optional - one of the built-in fonts, e.g. FontSans, or the name of an uploaded font
+	Bold       bool              // Optional
+	Italic     bool              // Optional
+	Align      TextAlign         // Optional - alignment of multi-line text, defaults to AlignLeft
+	Direction  TextDirection     // Optional - defaults to DirectionAuto
+	Stroke     *TextStroke       // Optional - outline drawn around the glyphs
+	Shadow     *TextShadow       // Optional - drop shadow drawn below the text
+	Background *TextBackground   // Optional - box drawn behind the text
+	Opacity    float32           // Opacity of Watermark between 0 and 1 inclusive
+	Position   WatermarkPosition // Where the Watermark should be placed. See WatermarkPosition
+	Offset     WatermarkOffset   // Optional - fine tune the placement. See WatermarkOffset
+	Timing     WatermarkTiming   // Optional - by default the Watermark is shown for the whole output
+	ZIndex     int               // Optional - watermarks with a higher ZIndex are drawn on top, ties keep list order
 }
 
 // WatermarkImageURL can be used to supply an image url which will be used as a Watermark
 type WatermarkImageURL struct {
-	URL      string            // URL where the Watermark image should be fetched from (currently, bucket urls are not supported)
-	Height   uint8             // Height of the Watermark
-	Width    uint8             // Width of the Watermark
-	Opacity  float32           // Opacity of Watermark between 0 and 1 inclusive
-	Position WatermarkPosition // Where the Watermark should be placed. See WatermarkPosition
+	URL          string            // URL where the Watermark image should be fetched from (currently, bucket urls are not supported)
+	Height       uint              // Height of the Watermark in pixels, calculated from the width to keep the image aspect ratio if not set
+	Width        uint              // Width of the Watermark in pixels, calculated from the height to keep the image aspect ratio if not set
+	WidthPercent float64           // Optional - Width as a percentage of the output width, the height follows the image aspect ratio
+	MinWidth     uint              // Optional - lower bound for the Watermark width in pixels, the height is scaled along
+	MaxWidth     uint              // Optional - upper bound for the Watermark width in pixels, the height is scaled along
+	Opacity      float32           // Opacity of Watermark between 0 and 1 inclusive
+	Position     WatermarkPosition // Where the Watermark should be placed. See WatermarkPosition
+	Offset       WatermarkOffset   // Optional - fine tune the placement. See WatermarkOffset
+	Timing       WatermarkTiming   // Optional - by default the Watermark is shown for the whole output
+	ZIndex       int               // Optional - watermarks with a higher ZIndex are drawn on top, ties keep list order
 }
 
 // WatermarkImageNamed can be used to provide a reference to a Watermark image uploaded to your mediamachine account
-// You can easily upload your Watermark images via account settings. The uploaded image gets a unique name that can be used here.
+// You can easily upload your Watermark images via account settings or MediaMachine.Watermarks().
+// The uploaded image gets a unique name that can be used here.
 type WatermarkImageNamed struct {
-	ImageName string            // Name of a Watermark image uploaded on the mediamachine account
-	Height    uint8             // Height of the Watermark
-	Width     uint8             // Width of the Watermark
-	Opacity   float32           // Opacity of Watermark between 0 and 1 inclusive
-	Position  WatermarkPosition // Where the Watermark should be placed. See WatermarkPosition
+	ImageName    string            // Name of a Watermark image uploaded on the mediamachine account
+	Height       uint              // Height of the Watermark in pixels, calculated from the width to keep the image aspect ratio if not set
+	Width        uint              // Width of the Watermark in pixels, calculated from the height to keep the image aspect ratio if not set
+	WidthPercent float64           // Optional - Width as a percentage of the output width, the height follows the image aspect ratio
+	MinWidth     uint              // Optional - lower bound for the Watermark width in pixels, the height is scaled along
+	MaxWidth     uint              // Optional - upper bound for the Watermark width in pixels, the height is scaled along
+	Opacity      float32           // Opacity of Watermark between 0 and 1 inclusive
+	Position     WatermarkPosition // Where the Watermark should be placed. See WatermarkPosition
+	Offset       WatermarkOffset   // Optional - fine tune the placement. See WatermarkOffset
+	Timing       WatermarkTiming   // Optional - by default the Watermark is shown for the whole output
+	ZIndex       int               // Optional - watermarks with a higher ZIndex are drawn on top, ties keep list order
+}
+
+/*
+WatermarkTiming restricts when a Watermark is visible in a video output.
+
+Times are relative to the start of the output and are sent to the API in whole milliseconds.
+Timing is not applicable to still outputs such as thumbnails.
+*/
+type WatermarkTiming struct {
+	Start   time.Duration `json:"-"` // Optional - the Watermark appears at Start, defaults to the beginning of the output
+	End     time.Duration `json:"-"` // Optional - the Watermark disappears at End, defaults to the end of the output
+	FadeIn  time.Duration `json:"-"` // Optional - duration of the fade in, starting at Start
+	FadeOut time.Duration `json:"-"` // Optional - duration of the fade out, ending at End
+}
+
+// MarshalJSON sends the times of t in milliseconds, as expected by the API.
+func (t WatermarkTiming) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		StartMS   int64
+		EndMS     int64
+		FadeInMS  int64
+		FadeOutMS int64
+	}{
+		StartMS:   t.Start.Milliseconds(),
+		EndMS:     t.End.Milliseconds(),
+		FadeInMS:  t.FadeIn.Milliseconds(),
+		FadeOutMS: t.FadeOut.Milliseconds(),
+	})
+}
+
+// ShowFirst returns a WatermarkTiming that shows the Watermark only during the first d of the output.
+func ShowFirst(d time.Duration) WatermarkTiming {
+	return WatermarkTiming{End: d}
+}
+
+// ShowBetween returns a WatermarkTiming that shows the Watermark from start until end.
+func ShowBetween(start, end time.Duration) WatermarkTiming {
+	return WatermarkTiming{Start: start, End: end}
+}
+
+func (t WatermarkTiming) validate() error {
+	if t.Start < 0 || t.End < 0 || t.FadeIn < 0 || t.FadeOut < 0 {
+		return fmt.Errorf("watermark timing cannot be negative")
+	}
+	if t.End == 0 {
+		return nil
+	}
+	if t.End <= t.Start {
+		return fmt.Errorf("watermark timing End (%s) must be after Start (%s)", t.End, t.Start)
+	}
+	if t.FadeIn+t.FadeOut > t.End-t.Start {
+		return fmt.Errorf("watermark fades (%s in, %s out) are longer than the %s it is shown for",
+			t.FadeIn, t.FadeOut, t.End-t.Start)
+	}
+	return nil
+}
+
+// validateWatermark checks a Watermark before it is submitted. Still outputs cannot use WatermarkTiming.
+func validateWatermark(wm Watermark, still bool) error {
+	switch w := wm.(type) {
+	case nil:
+		return nil
+	case WatermarkText:
+		if err := validateText(w); err != nil {
+			return err
+		}
+	case WatermarkImageURL:
+		if w.URL == "" {
+			return fmt.Errorf("watermark URL cannot be empty")
+		}
+		if err := validateImageSizing(w.Width, w.Height, w.WidthPercent, w.MinWidth, w.MaxWidth); err != nil {
+			return err
+		}
+	case WatermarkImageNamed:
+		if w.ImageName == "" {
+			return fmt.Errorf("watermark ImageName cannot be empty")
+		}
+		if err := validateImageSizing(w.Width, w.Height, w.WidthPercent, w.MinWidth, w.MaxWidth); err != nil {
+			return err
+		}
+	}
+
+	c := commonFields(wm)
+	if c.opacity < 0 || c.opacity > 1 {
+		return fmt.Errorf("watermark Opacity must be between 0 and 1, got %g", c.opacity)
+	}
+	switch c.position {
+	case "", PositionTopLeft, PositionTopRight, PositionBottomLeft, PositionBottomRight,
+		PositionCenter, PositionTopCenter, PositionBottomCenter, PositionTile:
+	default:
+		return fmt.Errorf("unsupported watermark Position: '%s'", c.position)
+	}
+	if c.offset.Percent && (math.Abs(c.offset.X) > 100 || math.Abs(c.offset.Y) > 100) {
+		return fmt.Errorf("watermark Offset percentages must be between -100 and 100")
+	}
+	if still && c.timing != (WatermarkTiming{}) {
+		return fmt.Errorf("watermark Timing is not applicable to still outputs")
+	}
+	return c.timing.validate()
+}
+
+func validateImageSizing(width, height uint, widthPercent float64, minWidth, maxWidth uint) error {
+	if width != 0 && widthPercent != 0 {
+		return fmt.Errorf("only one of watermark Width and WidthPercent can be set")
+	}
+	if height != 0 && widthPercent != 0 {
+		return fmt.Errorf("watermark Height cannot be set with WidthPercent, it follows the image aspect ratio")
+	}
+	if widthPercent < 0 || widthPercent > 100 {
+		return fmt.Errorf("watermark WidthPercent must be between 0 and 100, got %g", widthPercent)
+	}
+	if maxWidth != 0 && minWidth > maxWidth {
+		return fmt.Errorf("watermark MinWidth (%d) cannot be larger than MaxWidth (%d)", minWidth, maxWidth)
+	}
+	return nil
 }
 
 // Watermark can be of multiple types - text watermark, image or a saved image reference watermark
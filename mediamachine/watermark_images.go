@@ -0,0 +1,188 @@
+package mediamachine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register the jpeg decoder for image.DecodeConfig
+	_ "image/png"  // register the png decoder for image.DecodeConfig
+	"io"
+	"io/ioutil"
+	"regexp"
+	"time"
+)
+
+// WatermarkFormat is the file format of a Watermark image uploaded to the mediamachine account
+type WatermarkFormat = string
+
+const (
+	// WatermarkFormatPNG is a PNG Watermark image
+	WatermarkFormatPNG WatermarkFormat = "png"
+	// WatermarkFormatJPEG is a JPEG Watermark image
+	WatermarkFormatJPEG WatermarkFormat = "jpeg"
+	// WatermarkFormatSVG is an SVG Watermark image
+	WatermarkFormatSVG WatermarkFormat = "svg"
+
+	// MaxWatermarkImageBytes is the largest Watermark image file that can be uploaded
+	MaxWatermarkImageBytes = 5 << 20
+	// MaxWatermarkImageSize is the largest width or height in pixels of an uploaded PNG or JPEG Watermark image
+	MaxWatermarkImageSize = 4096
+)
+
+// watermarkNamePattern matches the names that can be given to uploaded Watermark images
+var watermarkNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,63}$`)
+
+var (
+	pngSignature  = []byte("\x89PNG\r\n\x1a\n")
+	jpegSignature = []byte{0xff, 0xd8, 0xff}
+)
+
+// WatermarkImage describes a Watermark image uploaded to the mediamachine account
+type WatermarkImage struct {
+	Name      string          // Name to use in WatermarkImageNamed
+	Format    WatermarkFormat // File format of the image
+	Width     uint            // Width of the image in pixels, 0 for SVG images
+	Height    uint            // Height of the image in pixels, 0 for SVG images
+	Bytes     int64           // Size of the image file
+	CreatedAt time.Time
+}
+
+/*
+WatermarkImages manages the Watermark images uploaded to the mediamachine account.
+
+Uploaded images can be referenced by name with WatermarkImageNamed. Use MediaMachine.Watermarks to get one.
+*/
+type WatermarkImages struct {
+	mm MediaMachine
+}
+
+// Watermarks gives access to the Watermark images uploaded to the mediamachine account.
+func (m MediaMachine) Watermarks() WatermarkImages {
+	return WatermarkImages{mm: m}
+}
+
+/*
+Upload stores a PNG, JPEG or SVG image on the mediamachine account under the given name,
+replacing any image previously uploaded with the same name.
+
+The image is checked locally before it is uploaded: the file cannot be larger than MaxWatermarkImageBytes
+and PNG/JPEG images cannot be wider or higher than MaxWatermarkImageSize.
+*/
+func (w WatermarkImages) Upload(ctx context.Context, name string, r io.Reader) (WatermarkImage, error) {
+	if err := validateWatermarkName(name); err != nil {
+		return WatermarkImage{}, err
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(r, MaxWatermarkImageBytes+1))
+	if err != nil {
+		return WatermarkImage{}, err
+	}
+	if len(data) > MaxWatermarkImageBytes {
+		return WatermarkImage{}, fmt.Errorf("watermark image is larger than %d bytes", MaxWatermarkImageBytes)
+	}
+	format, err := detectWatermarkFormat(data)
+	if err != nil {
+		return WatermarkImage{}, err
+	}
+
+	req := struct {
+		APIKey string
+		Name   string
+		Format WatermarkFormat
+		Data   []byte
+	}{
+		APIKey: w.mm.APIKey,
+		Name:   name,
+		Format: format,
+		Data:   data,
+	}
+	var img WatermarkImage
+	err = w.mm.call(ctx, "/watermark/upload", req, &img)
+	return img, err
+}
+
+// List returns all the Watermark images uploaded to the mediamachine account.
+func (w WatermarkImages) List(ctx context.Context) ([]WatermarkImage, error) {
+	req := struct {
+		APIKey string
+	}{
+		APIKey: w.mm.APIKey,
+	}
+	var resp struct {
+		Images []WatermarkImage
+	}
+	err := w.mm.call(ctx, "/watermark/list", req, &resp)
+	return resp.Images, err
+}
+
+// Get returns the details of the Watermark image uploaded under the given name.
+func (w WatermarkImages) Get(ctx context.Context, name string) (WatermarkImage, error) {
+	if err := validateWatermarkName(name); err != nil {
+		return WatermarkImage{}, err
+	}
+	var img WatermarkImage
+	err := w.mm.call(ctx, "/watermark/get", w.nameRequest(name), &img)
+	return img, err
+}
+
+// Delete removes the Watermark image uploaded under the given name.
+// Jobs referencing the image with WatermarkImageNamed fail once it is deleted.
+func (w WatermarkImages) Delete(ctx context.Context, name string) error {
+	if err := validateWatermarkName(name); err != nil {
+		return err
+	}
+	return w.mm.call(ctx, "/watermark/delete", w.nameRequest(name), nil)
+}
+
+func (w WatermarkImages) nameRequest(name string) interface{} {
+	return struct {
+		APIKey string
+		Name   string
+	}{
+		APIKey: w.mm.APIKey,
+		Name:   name,
+	}
+}
+
+func validateWatermarkName(name string) error {
+	if !watermarkNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid watermark image name: '%s'", name)
+	}
+	return nil
+}
+
+// detectWatermarkFormat identifies the format of an image file and checks its dimensions
+func detectWatermarkFormat(data []byte) (WatermarkFormat, error) {
+	switch {
+	case bytes.HasPrefix(data, pngSignature), bytes.HasPrefix(data, jpegSignature):
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return "", fmt.Errorf("invalid watermark image: %s", err)
+		}
+		if cfg.Width > MaxWatermarkImageSize || cfg.Height > MaxWatermarkImageSize {
+			return "", fmt.Errorf("watermark image is %dx%d, it cannot be larger than %dx%d",
+				cfg.Width, cfg.Height, MaxWatermarkImageSize, MaxWatermarkImageSize)
+		}
+		if format == "png" {
+			return WatermarkFormatPNG, nil
+		}
+		return WatermarkFormatJPEG, nil
+	case isSVG(data):
+		return WatermarkFormatSVG, nil
+	}
+	return "", fmt.Errorf("unsupported watermark image format, expected PNG, JPEG or SVG")
+}
+
+func isSVG(data []byte) bool {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("<?xml")) && !bytes.HasPrefix(data, []byte("<svg")) && !bytes.HasPrefix(data, []byte("<!--")) {
+		return false
+	}
+	head := data
+	if len(head) > 4096 {
+		head = head[:4096]
+	}
+	return bytes.Contains(head, []byte("<svg"))
+}
@@ -0,0 +1,40 @@
+package mediamachine_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/stackrock/mediamachinego/mediamachine"
+)
+
+var _ = Describe("WatermarkImages", func() {
+	images := mediamachine.MediaMachine{}.Watermarks()
+	ctx := context.Background()
+
+	It("rejects invalid names", func() {
+		_, err := images.Upload(ctx, "../logo", strings.NewReader("<svg></svg>"))
+		Expect(err).To(MatchError(ContainSubstring("invalid watermark image name")))
+		Expect(images.Delete(ctx, "")).To(MatchError(ContainSubstring("invalid watermark image name")))
+	})
+
+	It("rejects unsupported formats", func() {
+		_, err := images.Upload(ctx, "logo", strings.NewReader("GIF89a"))
+		Expect(err).To(MatchError(ContainSubstring("unsupported watermark image format")))
+	})
+
+	It("rejects images that are too large", func() {
+		var buf bytes.Buffer
+		Expect(png.Encode(&buf, image.NewGray(image.Rect(0, 0, mediamachine.MaxWatermarkImageSize+1, 1)))).To(Succeed())
+		_, err := images.Upload(ctx, "logo", &buf)
+		Expect(err).To(MatchError(ContainSubstring("cannot be larger than")))
+
+		_, err = images.Upload(ctx, "logo", bytes.NewReader(make([]byte, mediamachine.MaxWatermarkImageBytes+1)))
+		Expect(err).To(MatchError(ContainSubstring("larger than")))
+	})
+})
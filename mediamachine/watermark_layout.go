@@ -0,0 +1,253 @@
+package mediamachine
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+)
+
+const (
+	// watermarkMargin is the default distance in pixels between a Watermark and the edges it is anchored to
+	watermarkMargin = 10
+	// defaultFontSize is the FontSize used when a WatermarkText does not set one
+	defaultFontSize = 10
+	// glyphWidthRatio approximates the average advance of a glyph relative to the font size
+	glyphWidthRatio = 0.6
+	// lineHeightRatio is the height of a line of text relative to the font size
+	lineHeightRatio = 1.2
+)
+
+// watermarkFields holds the fields shared by all Watermark types
+type watermarkFields struct {
+	opacity  float32
+	position WatermarkPosition
+	offset   WatermarkOffset
+	timing   WatermarkTiming
+	zIndex   int
+}
+
+func commonFields(wm Watermark) watermarkFields {
+	switch wm := wm.(type) {
+	case WatermarkText:
+		return watermarkFields{wm.Opacity, wm.Position, wm.Offset, wm.Timing, wm.ZIndex}
+	case WatermarkImageURL:
+		return watermarkFields{wm.Opacity, wm.Position, wm.Offset, wm.Timing, wm.ZIndex}
+	case WatermarkImageNamed:
+		return watermarkFields{wm.Opacity, wm.Position, wm.Offset, wm.Timing, wm.ZIndex}
+	}
+	return watermarkFields{}
+}
+
+// watermarkSize returns the size in pixels of a Watermark on an output frame of the given size,
+// or zero values if it cannot be known locally
+func watermarkSize(wm Watermark, out Size) (int, int) {
+	switch wm := wm.(type) {
+	case WatermarkText:
+		return measureText(wm)
+	case WatermarkImageURL:
+		return imageSize(wm.Width, wm.Height, wm.MinWidth, wm.MaxWidth)
+	case WatermarkImageNamed:
+		return imageSize(wm.Width, wm.Height, wm.MinWidth, wm.MaxWidth)
+	}
+	return 0, 0
+}
+
+// imageSize resolves the size of an image Watermark. MinWidth and MaxWidth scale the height along with the width
+// to keep the aspect ratio. The aspect ratio of the image is unknown locally, so the size is only known when both
+// Width and Height are given.
+func imageSize(width, height, minWidth, maxWidth uint) (int, int) {
+	if width == 0 || height == 0 {
+		return 0, 0
+	}
+	w, h := float64(width), float64(height)
+	if minWidth != 0 && w < float64(minWidth) {
+		w, h = float64(minWidth), h*float64(minWidth)/w
+	}
+	if maxWidth != 0 && w > float64(maxWidth) {
+		w, h = float64(maxWidth), h*float64(maxWidth)/w
+	}
+	return int(math.Round(w)), int(math.Round(h))
+}
+
+/*
+WatermarkRect computes the area of an output frame of the given size that is covered by the Watermark.
+
+Text watermarks are measured with an approximation of the font metrics, so the rectangle is an estimate.
+For PositionTile, the rectangle of the first tile is returned, see WatermarkTiles for the whole pattern.
+Returns false when the size of the Watermark cannot be known locally, e.g. an image Watermark without
+Width or Height.
+*/
+func WatermarkRect(wm Watermark, out Size) (image.Rectangle, bool) {
+	w, h := watermarkSize(wm, out)
+	if w == 0 || h == 0 {
+		return image.Rectangle{}, false
+	}
+	c := commonFields(wm)
+
+	margin := watermarkMargin
+	if c.offset.Margin != 0 {
+		margin = int(c.offset.Margin)
+	}
+	ow, oh := int(out.Width), int(out.Height)
+
+	var x, y int
+	switch c.position {
+	case PositionTopLeft:
+		x, y = margin, margin
+	case PositionTopRight:
+		x, y = ow-margin-w, margin
+	case PositionBottomLeft:
+		x, y = margin, oh-margin-h
+	case PositionCenter:
+		x, y = (ow-w)/2, (oh-h)/2
+	case PositionTopCenter:
+		x, y = (ow-w)/2, margin
+	case PositionBottomCenter:
+		x, y = (ow-w)/2, oh-margin-h
+	case PositionTile:
+		x, y = 0, 0
+	default:
+		x, y = ow-margin-w, oh-margin-h
+	}
+
+	dx, dy := c.offset.X, c.offset.Y
+	if c.offset.Percent {
+		dx, dy = dx*float64(ow)/100, dy*float64(oh)/100
+	}
+	x += int(math.Round(dx))
+	y += int(math.Round(dy))
+	return image.Rect(x, y, x+w, y+h), true
+}
+
+// WatermarkTiles returns the rectangles covered by a Watermark with PositionTile on an output frame of the
+// given size. For other positions, it returns the single rectangle from WatermarkRect.
+func WatermarkTiles(wm Watermark, out Size) []image.Rectangle {
+	first, ok := WatermarkRect(wm, out)
+	if !ok {
+		return nil
+	}
+	c := commonFields(wm)
+	if c.position != PositionTile {
+		return []image.Rectangle{first}
+	}
+
+	gap := watermarkMargin
+	if c.offset.Margin != 0 {
+		gap = int(c.offset.Margin)
+	}
+	stepX, stepY := first.Dx()+gap, first.Dy()+gap
+	frame := image.Rect(0, 0, int(out.Width), int(out.Height))
+
+	// start from the tile closest to the top left corner, so that shifted patterns still cover the frame
+	x0 := first.Min.X - int(math.Ceil(float64(first.Min.X)/float64(stepX)))*stepX
+	y0 := first.Min.Y - int(math.Ceil(float64(first.Min.Y)/float64(stepY)))*stepY
+
+	var tiles []image.Rectangle
+	for y := y0; y < frame.Max.Y; y += stepY {
+		for x := x0; x < frame.Max.X; x += stepX {
+			tile := image.Rect(x, y, x+first.Dx(), y+first.Dy())
+			if tile.Overlaps(frame) {
+				tiles = append(tiles, tile)
+			}
+		}
+	}
+	return tiles
+}
+
+// watermarkLayers combines the single Watermark field of a config with its Watermarks list.
+// The single Watermark comes first, so that it is drawn below the list when ZIndex values are equal.
+func watermarkLayers(single Watermark, list []Watermark) []Watermark {
+	if single == nil {
+		return list
+	}
+	return append([]Watermark{single}, list...)
+}
+
+// payloadLayers returns the Watermark and Watermarks fields sent to the API. The API draws the Watermarks list
+// in order over Watermark, so when a list is given all layers are sent in it, sorted by ZIndex.
+func payloadLayers(single Watermark, list []Watermark) (Watermark, []Watermark) {
+	if len(list) == 0 {
+		return single, list
+	}
+	return nil, SortWatermarks(watermarkLayers(single, list))
+}
+
+// SortWatermarks returns the watermarks in drawing order, from the bottom layer to the top layer.
+func SortWatermarks(wms []Watermark) []Watermark {
+	sorted := append([]Watermark(nil), wms...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return commonFields(sorted[i]).zIndex < commonFields(sorted[j]).zIndex
+	})
+	return sorted
+}
+
+// overlapsInTime reports whether two watermarks can be visible at the same time
+func (t WatermarkTiming) overlapsInTime(o WatermarkTiming) bool {
+	if t.End != 0 && t.End <= o.Start {
+		return false
+	}
+	if o.End != 0 && o.End <= t.Start {
+		return false
+	}
+	return true
+}
+
+// exactSize reports whether the size of a Watermark from WatermarkRect is exact rather than estimated
+func exactSize(wm Watermark) bool {
+	_, text := wm.(WatermarkText)
+	return !text
+}
+
+/*
+validateWatermarks checks every layer of a config. When the output Width is known, it checks that every layer
+fits horizontally in the output, and when both Width and Height are known, that the layers fit vertically
+and no two layers are drawn over each other at the same time. Tiled layers are expected to cover other layers.
+
+Only layers with an exact size are checked: image watermarks setting only one of Width and Height get the other
+one from the aspect ratio of the image, which is not known before the job runs, and text layers are only estimated. Use WatermarkRect to inspect where text layers are expected to be drawn.
+*/
+func validateWatermarks(wms []Watermark, still bool, out Size) error {
+	for i, wm := range wms {
+		if wm == nil {
+			return fmt.Errorf("watermark %d is nil", i)
+		}
+		if err := validateWatermark(wm, still); err != nil {
+			return fmt.Errorf("watermark %d: %s", i, err)
+		}
+	}
+	if out.Width == 0 {
+		return nil
+	}
+
+	rects := make([]image.Rectangle, len(wms))
+	known := make([]bool, len(wms))
+	for i, wm := range wms {
+		rects[i], known[i] = WatermarkRect(wm, out)
+		if !known[i] || !exactSize(wm) || commonFields(wm).position == PositionTile {
+			known[i] = false
+			continue
+		}
+		if rects[i].Min.X < 0 || rects[i].Max.X > int(out.Width) {
+			return fmt.Errorf("watermark %d does not fit horizontally in the %dpx wide output", i, out.Width)
+		}
+		if out.Height != 0 && (rects[i].Min.Y < 0 || rects[i].Max.Y > int(out.Height)) {
+			return fmt.Errorf("watermark %d does not fit vertically in the %dpx high output", i, out.Height)
+		}
+	}
+	if out.Height == 0 {
+		return nil
+	}
+
+	for i := range wms {
+		for j := i + 1; j < len(wms); j++ {
+			if !known[i] || !known[j] || !rects[i].Overlaps(rects[j]) {
+				continue
+			}
+			if commonFields(wms[i]).timing.overlapsInTime(commonFields(wms[j]).timing) {
+				return fmt.Errorf("watermarks %d and %d overlap on the %dx%d output", i, j, out.Width, out.Height)
+			}
+		}
+	}
+	return nil
+}
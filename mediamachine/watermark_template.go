@@ -0,0 +1,154 @@
+package mediamachine
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// metaKeyPattern matches the Meta keys that can be referenced as {{.Meta.key}}
+var metaKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+/*
+TextTemplateData holds the variables available to a templated WatermarkText:
+
+	{{.Timecode}}  position of the frame in the output, formatted as HH:MM:SS
+	{{.Date}}      date the job is processed, formatted as YYYY-MM-DD
+	{{.JobID}}     ID of the job rendering the Watermark
+	{{.Meta.key}}  value of key in the Meta field of the WatermarkText
+*/
+type TextTemplateData struct {
+	Timecode string
+	Date     string
+	JobID    string
+	Meta     map[string]string
+}
+
+// IsTemplate reports whether the Text of the Watermark is a template.
+func (w WatermarkText) IsTemplate() bool {
+	return strings.Contains(w.Text, "{{")
+}
+
+/*
+RenderText renders a templated Text locally, as MediaMachine would for the frame at the given position of the output.
+Text that is not a template is returned as it is.
+*/
+func (w WatermarkText) RenderText(position time.Duration, date time.Time, jobID string) (string, error) {
+	if !w.IsTemplate() {
+		return w.Text, nil
+	}
+	tmpl, err := w.parseTemplate()
+	if err != nil {
+		return "", err
+	}
+
+	position = position.Truncate(time.Second)
+	data := TextTemplateData{
+		Timecode: fmt.Sprintf("%02d:%02d:%02d",
+			int(position.Hours()), int(position.Minutes())%60, int(position.Seconds())%60),
+		Date:  date.Format("2006-01-02"),
+		JobID: jobID,
+		Meta:  w.Meta,
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (w WatermarkText) parseTemplate() (*template.Template, error) {
+	tmpl, err := template.New("watermark").Option("missingkey=error").Parse(w.Text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid watermark Text template: %s", err)
+	}
+	return tmpl, nil
+}
+
+// validateTemplate checks that a templated Text parses and only references known variables
+func validateTemplate(w WatermarkText) error {
+	for key := range w.Meta {
+		if !metaKeyPattern.MatchString(key) {
+			return fmt.Errorf("watermark Meta key '%s' must only contain letters, digits and underscores", key)
+		}
+	}
+	if !w.IsTemplate() {
+		return nil
+	}
+
+	tmpl, err := w.parseTemplate()
+	if err != nil {
+		return err
+	}
+	return checkTemplateNode(tmpl.Tree.Root, w.Meta)
+}
+
+func checkTemplateNode(node parse.Node, meta map[string]string) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkTemplateNode(child, meta); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		return checkTemplateNode(n.Pipe, meta)
+	case *parse.IfNode:
+		for _, child := range []parse.Node{n.Pipe, n.List, n.ElseList} {
+			if err := checkTemplateNode(child, meta); err != nil {
+				return err
+			}
+		}
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, cmd := range n.Cmds {
+			if err := checkTemplateNode(cmd, meta); err != nil {
+				return err
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if err := checkTemplateNode(arg, meta); err != nil {
+				return err
+			}
+		}
+	case *parse.FieldNode:
+		return checkTemplateField(n.Ident, meta)
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			return checkTemplateField(n.Ident[1:], meta)
+		}
+		return fmt.Errorf("watermark Text template variables are not supported: %s", n)
+	case *parse.ChainNode:
+		return fmt.Errorf("watermark Text template field chains are not supported: %s", n)
+	case *parse.RangeNode, *parse.WithNode, *parse.TemplateNode:
+		return fmt.Errorf("watermark Text template actions other than if/else are not supported: %s", n)
+	}
+	return nil
+}
+
+func checkTemplateField(ident []string, meta map[string]string) error {
+	switch ident[0] {
+	case "Timecode", "Date", "JobID":
+		if len(ident) == 1 {
+			return nil
+		}
+	case "Meta":
+		if len(ident) == 2 {
+			if _, ok := meta[ident[1]]; ok {
+				return nil
+			}
+			return fmt.Errorf("watermark Text template references .Meta.%s, which is not set in Meta", ident[1])
+		}
+	}
+	return fmt.Errorf("watermark Text template references unknown variable .%s", strings.Join(ident, "."))
+}
@@ -0,0 +1,412 @@
+package mediamachine_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/stackrock/mediamachinego/colors"
+	"github.com/stackrock/mediamachinego/mediamachine"
+)
+
+var _ = Describe("Watermark layout", func() {
+	out := mediamachine.Size{Width: 1280, Height: 720}
+
+	It("anchors watermarks to the corners with a margin", func() {
+		r, ok := mediamachine.WatermarkRect(mediamachine.WatermarkImageNamed{
+			ImageName: "logo",
+			Width:     100,
+			Height:    50,
+			Position:  mediamachine.PositionTopRight,
+		}, out)
+		Expect(ok).To(BeTrue())
+		Expect(r).To(Equal(image.Rect(1170, 10, 1270, 60)))
+	})
+
+	It("applies offsets in percent of the output and custom margins", func() {
+		r, ok := mediamachine.WatermarkRect(mediamachine.WatermarkImageURL{
+			URL:      "https://example.com/logo.png",
+			Width:    100,
+			Height:   50,
+			Position: mediamachine.PositionBottomCenter,
+			Offset:   mediamachine.WatermarkOffset{X: 10, Percent: true, Margin: 20},
+		}, out)
+		Expect(ok).To(BeTrue())
+		Expect(r).To(Equal(image.Rect(718, 650, 818, 700)))
+	})
+
+	It("repeats tiled watermarks over the whole output", func() {
+		tiles := mediamachine.WatermarkTiles(mediamachine.WatermarkImageNamed{
+			ImageName: "logo",
+			Width:     200,
+			Height:    200,
+			Position:  mediamachine.PositionTile,
+			Offset:    mediamachine.WatermarkOffset{Margin: 100},
+		}, out)
+		Expect(tiles).To(HaveLen(5 * 3))
+		Expect(tiles[0]).To(Equal(image.Rect(0, 0, 200, 200)))
+	})
+
+	It("scales the height of clamped image watermarks to keep the aspect ratio", func() {
+		logo := mediamachine.WatermarkImageNamed{
+			ImageName: "logo",
+			Width:     640,
+			Height:    400,
+			MaxWidth:  320,
+			Position:  mediamachine.PositionTopLeft,
+		}
+		r, ok := mediamachine.WatermarkRect(logo, out)
+		Expect(ok).To(BeTrue())
+		Expect(r).To(Equal(image.Rect(10, 10, 330, 210)))
+
+		logo = mediamachine.WatermarkImageNamed{ImageName: "logo", Width: 100, Height: 50, MinWidth: 200}
+		r, ok = mediamachine.WatermarkRect(logo, out)
+		Expect(ok).To(BeTrue())
+		Expect(r.Size()).To(Equal(image.Pt(200, 100)))
+	})
+
+	It("sizes image watermarks in pixels beyond 255", func() {
+		r, ok := mediamachine.WatermarkRect(mediamachine.WatermarkImageURL{
+			URL:      "https://example.com/logo.png",
+			Width:    200,
+			Height:   300,
+			Position: mediamachine.PositionTopLeft,
+		}, out)
+		Expect(ok).To(BeTrue())
+		Expect(r).To(Equal(image.Rect(10, 10, 210, 310)))
+	})
+
+	It("cannot measure image watermarks sized relative to the output", func() {
+		_, ok := mediamachine.WatermarkRect(mediamachine.WatermarkImageURL{URL: "https://example.com/logo.png", WidthPercent: 20}, out)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("cannot measure image watermarks without a size", func() {
+		_, ok := mediamachine.WatermarkRect(mediamachine.WatermarkImageURL{URL: "https://example.com/logo.png"}, out)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("sorts layers by ZIndex, keeping list order for ties", func() {
+		a := mediamachine.WatermarkText{Text: "a", ZIndex: 1}
+		b := mediamachine.WatermarkText{Text: "b"}
+		c := mediamachine.WatermarkText{Text: "c", ZIndex: 1}
+		Expect(mediamachine.SortWatermarks([]mediamachine.Watermark{a, b, c})).To(Equal([]mediamachine.Watermark{b, a, c}))
+	})
+})
+
+var _ = Describe("Watermark validation", func() {
+	mm := mediamachine.MediaMachine{}
+	api := &fakeAPI{response: `{"id":"job-1"}`}
+	var restore func()
+	BeforeEach(func() { restore = mediamachine.UseTransport(api) })
+	AfterEach(func() { restore() })
+	logo := mediamachine.WatermarkImageNamed{ImageName: "logo", Width: 200, Height: 100, Position: mediamachine.PositionBottomLeft}
+
+	It("rejects overlapping layers when the output size is known", func() {
+		_, err := mm.Thumbnail(mediamachine.ThumbnailConfig{
+			InputURL:   "https://example.com/input.mp4",
+			OutputURL:  "https://example.com/output.jpg",
+			Width:      400,
+			Height:     300,
+			Watermarks: []mediamachine.Watermark{logo, mediamachine.WatermarkImageNamed{ImageName: "badge", Width: 200, Height: 50, Position: mediamachine.PositionBottomRight}},
+		})
+		Expect(err).To(MatchError(ContainSubstring("overlap")))
+	})
+
+	It("does not reject text layers from their estimated size", func() {
+		_, err := mm.Thumbnail(mediamachine.ThumbnailConfig{
+			InputURL:   "https://example.com/input.mp4",
+			OutputURL:  "https://example.com/output.jpg",
+			Width:      400,
+			Height:     300,
+			Watermarks: []mediamachine.Watermark{logo, mediamachine.WatermarkText{Text: "© Example Studios", FontSize: 20, Position: mediamachine.PositionBottomRight}},
+		})
+		Expect(err).To(BeNil())
+	})
+
+	It("rejects fades longer than the time window", func() {
+		_, err := mm.SummaryMP4(mediamachine.SummaryConfig{
+			InputURL:  "https://example.com/input.mp4",
+			OutputURL: "https://example.com/output.mp4",
+			Watermark: mediamachine.WatermarkText{
+				Text: "hello",
+				Timing: mediamachine.WatermarkTiming{
+					Start:   time.Second,
+					End:     time.Second * 3,
+					FadeIn:  time.Second * 2,
+					FadeOut: time.Second,
+				},
+			},
+		})
+		Expect(err).To(MatchError(ContainSubstring("fades")))
+	})
+
+	It("rejects watermarks pushed outside of the output width", func() {
+		_, err := mm.SummaryGIF(mediamachine.SummaryConfig{
+			InputURL:  "https://example.com/input.mp4",
+			OutputURL: "https://example.com/output.gif",
+			Width:     300,
+			Watermark: mediamachine.WatermarkImageNamed{
+				ImageName: "logo",
+				Width:     100,
+				Height:    100,
+				Position:  mediamachine.PositionTopRight,
+				Offset:    mediamachine.WatermarkOffset{X: 20},
+			},
+		})
+		Expect(err).To(MatchError(ContainSubstring("does not fit horizontally")))
+	})
+
+	It("does not reject text pushed outside of the output width from its estimated size", func() {
+		_, err := mm.SummaryGIF(mediamachine.SummaryConfig{
+			InputURL:  "https://example.com/input.mp4",
+			OutputURL: "https://example.com/output.gif",
+			Width:     300,
+			Watermark: mediamachine.WatermarkText{
+				Text:     "© Example Studios",
+				FontSize: 20,
+				Position: mediamachine.PositionTopRight,
+				Offset:   mediamachine.WatermarkOffset{X: 20},
+			},
+		})
+		Expect(err).To(BeNil())
+	})
+
+	It("rejects image sizes set twice", func() {
+		thumbnail := func(wm mediamachine.Watermark) error {
+			_, err := mm.Thumbnail(mediamachine.ThumbnailConfig{
+				InputURL:  "https://example.com/input.mp4",
+				OutputURL: "https://example.com/output.jpg",
+				Watermark: wm,
+			})
+			return err
+		}
+		Expect(thumbnail(mediamachine.WatermarkImageNamed{ImageName: "logo", Width: 100, WidthPercent: 10})).To(
+			MatchError(ContainSubstring("Width and WidthPercent")))
+		Expect(thumbnail(mediamachine.WatermarkImageNamed{ImageName: "logo", Height: 100, WidthPercent: 10})).To(
+			MatchError(ContainSubstring("aspect ratio")))
+	})
+
+	It("rejects timing on still outputs", func() {
+		_, err := mm.Thumbnail(mediamachine.ThumbnailConfig{
+			InputURL:  "https://example.com/input.mp4",
+			OutputURL: "https://example.com/output.jpg",
+			Watermark: mediamachine.WatermarkText{Text: "hello", Timing: mediamachine.ShowFirst(time.Second)},
+		})
+		Expect(err).To(MatchError(ContainSubstring("still outputs")))
+	})
+
+	It("rejects text styles with invalid colors", func() {
+		_, err := mm.Thumbnail(mediamachine.ThumbnailConfig{
+			InputURL:  "https://example.com/input.mp4",
+			OutputURL: "https://example.com/output.jpg",
+			Watermark: mediamachine.WatermarkText{
+				Text:   "© Example Studios",
+				Stroke: &mediamachine.TextStroke{Color: "#12345", Width: 2},
+			},
+		})
+		Expect(err).To(MatchError(ContainSubstring("Stroke Color")))
+	})
+})
+
+var _ = Describe("WatermarkText", func() {
+	It("measures multi-line text with its background", func() {
+		r, ok := mediamachine.WatermarkRect(mediamachine.WatermarkText{
+			Text:       "Directed by\nJane Doe",
+			FontSize:   20,
+			Position:   mediamachine.PositionTopLeft,
+			Background: &mediamachine.TextBackground{Color: "black@0.5", Padding: 5},
+		}, mediamachine.Size{Width: 1280, Height: 720})
+		Expect(ok).To(BeTrue())
+		Expect(r).To(Equal(image.Rect(10, 10, 10+132+10, 10+48+10)))
+	})
+
+	It("picks readable colors for the footage behind the text", func() {
+		dark := image.NewUniform(color.NRGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xff})
+		wm, err := mediamachine.WatermarkText{Text: "© Example Studios"}.WithAutoColor(image.NewRGBA(image.Rect(0, 0, 1, 1)))
+		Expect(err).To(BeNil())
+		Expect(wm.FontColor).To(Equal(colors.White))
+
+		sample := image.NewRGBA(image.Rect(0, 0, 10, 10))
+		draw.Draw(sample, sample.Bounds(), dark, image.Point{}, draw.Src)
+		wm, err = mediamachine.WatermarkText{Text: "© Example Studios", Stroke: &mediamachine.TextStroke{Width: 3}}.WithAutoColor(sample)
+		Expect(err).To(BeNil())
+		Expect(wm.FontColor).To(Equal(colors.White))
+		Expect(*wm.Stroke).To(Equal(mediamachine.TextStroke{Color: colors.Black, Width: 3}))
+
+		wm, err = mediamachine.WatermarkText{Text: "© Example Studios"}.WithAutoColor(sample, colors.Yellow)
+		Expect(err).To(BeNil())
+		Expect(wm.FontColor).To(Equal(colors.Yellow))
+		Expect(wm.Stroke.Color).To(Equal(colors.Black))
+	})
+
+	It("rejects palettes without a valid color", func() {
+		wm := mediamachine.WatermarkText{Text: "© Example Studios", FontColor: colors.Red}
+		out, err := wm.WithAutoColor(image.NewRGBA(image.Rect(0, 0, 1, 1)), "not-a-color")
+		Expect(err).To(MatchError(ContainSubstring("no valid color")))
+		Expect(out).To(Equal(wm))
+	})
+
+	It("splits rendered text into lines", func() {
+		Expect(mediamachine.WatermarkText{Text: "Directed by\nJane Doe"}.Lines()).To(Equal([]string{"Directed by", "Jane Doe"}))
+	})
+
+	It("detects right-to-left text", func() {
+		Expect(mediamachine.WatermarkText{Text: "«שלום»"}.IsRTL()).To(BeTrue())
+		Expect(mediamachine.WatermarkText{Text: "Hello"}.IsRTL()).To(BeFalse())
+		Expect(mediamachine.WatermarkText{Text: "Hello", Direction: mediamachine.DirectionRTL}.IsRTL()).To(BeTrue())
+	})
+})
+
+var _ = Describe("WatermarkText templates", func() {
+	mm := mediamachine.MediaMachine{}
+	submit := func(wm mediamachine.WatermarkText) error {
+		_, err := mm.Thumbnail(mediamachine.ThumbnailConfig{
+			InputURL:  "https://example.com/input.mp4",
+			OutputURL: "https://example.com/output.jpg",
+			Watermark: wm,
+		})
+		return err
+	}
+
+	It("renders templates locally", func() {
+		wm := mediamachine.WatermarkText{
+			Text: "{{.Meta.viewerEmail}} {{.Date}} {{.Timecode}}{{if .JobID}} #{{.JobID}}{{end}}",
+			Meta: map[string]string{"viewerEmail": "jane@example.com"},
+		}
+		date := time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)
+		text, err := wm.RenderText(time.Hour+time.Minute*2+time.Second*3+time.Millisecond*500, date, "job-1")
+		Expect(err).To(BeNil())
+		Expect(text).To(Equal("jane@example.com 2021-03-04 01:02:03 #job-1"))
+	})
+
+	It("rejects unknown variables before submission", func() {
+		Expect(submit(mediamachine.WatermarkText{Text: "{{.Viewer}}"})).To(MatchError(ContainSubstring("unknown variable .Viewer")))
+		Expect(submit(mediamachine.WatermarkText{Text: "{{.Meta.viewerEmail}}"})).To(MatchError(ContainSubstring("not set in Meta")))
+		Expect(submit(mediamachine.WatermarkText{Text: "{{.JobID"})).To(MatchError(ContainSubstring("invalid watermark Text template")))
+		Expect(submit(mediamachine.WatermarkText{Text: "{{range .Meta}}{{.}}{{end}}"})).To(MatchError(ContainSubstring("not supported")))
+	})
+})
+
+var _ = Describe("WatermarkTiming", func() {
+	mm := mediamachine.MediaMachine{APIKey: "key"}
+	api := &fakeAPI{response: `{"id":"job-1"}`}
+	var restore func()
+	BeforeEach(func() { restore = mediamachine.UseTransport(api) })
+	AfterEach(func() { restore() })
+
+	transcode := func(timing mediamachine.WatermarkTiming) error {
+		_, err := mm.Transcode(mediamachine.TranscodeConfig{
+			Container:   mediamachine.ContainerMP4,
+			Encoder:     mediamachine.EncoderH264,
+			BitrateKBPS: mediamachine.Bitrate2Mbps,
+			InputURL:    "https://example.com/input.mp4",
+			OutputURL:   "https://example.com/output.mp4",
+			Watermark:   mediamachine.WatermarkText{Text: "hello", Timing: timing},
+		})
+		return err
+	}
+
+	It("builds common time windows", func() {
+		Expect(mediamachine.ShowFirst(5 * time.Second)).To(Equal(mediamachine.WatermarkTiming{End: 5 * time.Second}))
+		Expect(mediamachine.ShowBetween(time.Second, 3*time.Second)).To(Equal(
+			mediamachine.WatermarkTiming{Start: time.Second, End: 3 * time.Second}))
+	})
+
+	It("accepts valid time windows", func() {
+		for _, t := range []mediamachine.WatermarkTiming{
+			{},
+			{Start: 10 * time.Second},
+			{Start: time.Second, End: 5 * time.Second, FadeIn: 2 * time.Second, FadeOut: 2 * time.Second},
+		} {
+			Expect(transcode(t)).To(BeNil(), "%+v", t)
+		}
+	})
+
+	It("sends the time window in milliseconds", func() {
+		Expect(transcode(mediamachine.WatermarkTiming{
+			Start: time.Second, End: 5 * time.Second, FadeIn: 1500 * time.Millisecond, FadeOut: 500 * time.Millisecond,
+		})).To(BeNil())
+		watermark := api.body["Watermark"].(map[string]interface{})
+		Expect(watermark["Timing"]).To(Equal(map[string]interface{}{
+			"StartMS": 1000.0, "EndMS": 5000.0, "FadeInMS": 1500.0, "FadeOutMS": 500.0,
+		}))
+	})
+
+	It("rejects invalid time windows", func() {
+		Expect(transcode(mediamachine.WatermarkTiming{Start: -time.Second})).To(MatchError(ContainSubstring("negative")))
+		Expect(transcode(mediamachine.ShowBetween(3*time.Second, time.Second))).To(MatchError(ContainSubstring("must be after")))
+		Expect(transcode(mediamachine.ShowBetween(time.Second, time.Second))).To(MatchError(ContainSubstring("must be after")))
+	})
+
+	It("validates watermarks of thumbnails and summaries", func() {
+		_, err := mm.Thumbnail(mediamachine.ThumbnailConfig{
+			InputURL:  "https://example.com/input.mp4",
+			OutputURL: "https://example.com/output.jpg",
+			Watermark: mediamachine.WatermarkText{},
+		})
+		Expect(err).To(MatchError(ContainSubstring("Text cannot be empty")))
+
+		_, err = mm.SummaryMP4(mediamachine.SummaryConfig{
+			InputURL:  "https://example.com/input.mp4",
+			OutputURL: "https://example.com/output.mp4",
+			Watermark: mediamachine.WatermarkImageURL{URL: "https://example.com/logo.png", Opacity: 1.5},
+		})
+		Expect(err).To(MatchError(ContainSubstring("Opacity")))
+	})
+})
+
+var _ = Describe("Watermark layers", func() {
+	mm := mediamachine.MediaMachine{APIKey: "key"}
+	api := &fakeAPI{response: `{"id":"job-1"}`}
+	var restore func()
+	BeforeEach(func() { restore = mediamachine.UseTransport(api) })
+	AfterEach(func() { restore() })
+
+	It("sends the layers in ZIndex order", func() {
+		_, err := mm.Thumbnail(mediamachine.ThumbnailConfig{
+			InputURL:  "https://example.com/input.mp4",
+			OutputURL: "https://example.com/output.jpg",
+			Watermark: mediamachine.WatermarkText{Text: "top", ZIndex: 2},
+			Watermarks: []mediamachine.Watermark{
+				mediamachine.WatermarkText{Text: "middle", ZIndex: 1},
+				mediamachine.WatermarkImageNamed{ImageName: "logo", Width: 100},
+			},
+		})
+		Expect(err).To(BeNil())
+		Expect(api.body["Watermark"]).To(BeNil())
+		layers := api.body["Watermarks"].([]interface{})
+		Expect(layers).To(HaveLen(3))
+		Expect(layers[0]).To(HaveKeyWithValue("ImageName", "logo"))
+		Expect(layers[1]).To(HaveKeyWithValue("Text", "middle"))
+		Expect(layers[2]).To(HaveKeyWithValue("Text", "top"))
+	})
+
+	It("keeps a single Watermark as it is", func() {
+		_, err := mm.Thumbnail(mediamachine.ThumbnailConfig{
+			InputURL:  "https://example.com/input.mp4",
+			OutputURL: "https://example.com/output.jpg",
+			Watermark: mediamachine.WatermarkText{Text: "hello", ZIndex: 2},
+		})
+		Expect(err).To(BeNil())
+		Expect(api.body["Watermark"]).To(HaveKeyWithValue("Text", "hello"))
+	})
+
+	It("does not check overlaps of images sized by their aspect ratio", func() {
+		_, err := mm.Thumbnail(mediamachine.ThumbnailConfig{
+			InputURL:  "https://example.com/input.mp4",
+			OutputURL: "https://example.com/output.jpg",
+			Width:     400,
+			Height:    300,
+			Watermarks: []mediamachine.Watermark{
+				mediamachine.WatermarkImageNamed{ImageName: "logo", Width: 200, Position: mediamachine.PositionBottomLeft},
+				mediamachine.WatermarkImageNamed{ImageName: "badge", Width: 200, Position: mediamachine.PositionBottomLeft},
+			},
+		})
+		Expect(err).To(BeNil())
+	})
+})
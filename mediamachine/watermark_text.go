@@ -0,0 +1,213 @@
+package mediamachine
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/stackrock/mediamachinego/colors"
+)
+
+// TextAlign is the horizontal alignment of the lines of a multi-line WatermarkText
+type TextAlign = string
+
+// TextDirection is the writing direction of a WatermarkText
+type TextDirection = string
+
+const (
+	// FontSans is the built-in sans-serif font, used by default
+	FontSans = "sans"
+	// FontSerif is the built-in serif font
+	FontSerif = "serif"
+	// FontMono is the built-in monospace font
+	FontMono = "monospace"
+	// FontCondensed is the built-in condensed sans-serif font
+	FontCondensed = "condensed"
+
+	// AlignLeft aligns lines on their left edge
+	AlignLeft TextAlign = "left"
+	// AlignCenter centers lines horizontally
+	AlignCenter TextAlign = "center"
+	// AlignRight aligns lines on their right edge
+	AlignRight TextAlign = "right"
+
+	// DirectionAuto detects the direction from the first strong character of the text
+	DirectionAuto TextDirection = ""
+	// DirectionLTR lays out the text left-to-right
+	DirectionLTR TextDirection = "ltr"
+	// DirectionRTL lays out the text right-to-left, e.g. for Arabic or Hebrew
+	DirectionRTL TextDirection = "rtl"
+)
+
+// fontNamePattern matches the names of built-in and uploaded fonts
+var fontNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9 _.-]{0,63}$`)
+
+// TextStroke is an outline drawn around the glyphs of a WatermarkText
+type TextStroke struct {
+	Color colors.Color // Color of the outline
+	Width uint         // Width of the outline in pixels
+}
+
+// TextShadow is a drop shadow drawn below a WatermarkText
+type TextShadow struct {
+	Color   colors.Color // Color of the shadow
+	OffsetX int          // Horizontal distance in pixels between the text and its shadow
+	OffsetY int          // Vertical distance in pixels between the text and its shadow
+	Blur    uint         // Optional - blur radius in pixels
+}
+
+// TextBackground is a box drawn behind a WatermarkText
+type TextBackground struct {
+	Color   colors.Color // Color of the box, use the @alpha suffix for a translucent box
+	Padding uint         // Optional - space in pixels between the text and the edges of the box
+}
+
+func validateText(w WatermarkText) error {
+	if w.Text == "" {
+		return fmt.Errorf("watermark Text cannot be empty")
+	}
+	if !utf8.ValidString(w.Text) {
+		return fmt.Errorf("watermark Text is not valid UTF-8")
+	}
+	for _, r := range w.Text {
+		if r != '\n' && unicode.IsControl(r) {
+			return fmt.Errorf("watermark Text contains control character %U", r)
+		}
+	}
+	if err := validateTemplate(w); err != nil {
+		return err
+	}
+
+	if w.FontColor != "" && !colors.Valid(w.FontColor) {
+		return fmt.Errorf("invalid watermark FontColor: '%s'", w.FontColor)
+	}
+	if w.FontFamily != "" && !fontNamePattern.MatchString(w.FontFamily) {
+		return fmt.Errorf("invalid watermark FontFamily: '%s'", w.FontFamily)
+	}
+
+	switch w.Align {
+	case "", AlignLeft, AlignCenter, AlignRight:
+	default:
+		return fmt.Errorf("unsupported watermark Align: '%s'", w.Align)
+	}
+	switch w.Direction {
+	case DirectionAuto, DirectionLTR, DirectionRTL:
+	default:
+		return fmt.Errorf("unsupported watermark Direction: '%s'", w.Direction)
+	}
+
+	if w.Stroke != nil {
+		if w.Stroke.Width == 0 {
+			return fmt.Errorf("watermark Stroke Width must be set")
+		}
+		if !colors.Valid(w.Stroke.Color) {
+			return fmt.Errorf("invalid watermark Stroke Color: '%s'", w.Stroke.Color)
+		}
+	}
+	if w.Shadow != nil && !colors.Valid(w.Shadow.Color) {
+		return fmt.Errorf("invalid watermark Shadow Color: '%s'", w.Shadow.Color)
+	}
+	if w.Background != nil && !colors.Valid(w.Background.Color) {
+		return fmt.Errorf("invalid watermark Background Color: '%s'", w.Background.Color)
+	}
+	return nil
+}
+
+// sampleJobID stands in for the job ID when a templated Text is rendered before the job exists
+const sampleJobID = "00000000-0000-0000-0000-000000000000"
+
+// Lines splits the text of a WatermarkText into its lines.
+// Templates are rendered with sample values, so that the lines have their expected length.
+func (w WatermarkText) Lines() []string {
+	text, err := w.RenderText(0, time.Now(), sampleJobID)
+	if err != nil {
+		text = w.Text
+	}
+	return strings.Split(text, "\n")
+}
+
+// measureText estimates the size in pixels of the box covered by a WatermarkText,
+// including its outline and background padding
+func measureText(w WatermarkText) (int, int) {
+	fontSize := w.FontSize
+	if fontSize == 0 {
+		fontSize = defaultFontSize
+	}
+	ratio := glyphWidthRatio
+	if w.Bold {
+		ratio += 0.05
+	}
+
+	lines := w.Lines()
+	longest := 0
+	for _, line := range lines {
+		if n := utf8.RuneCountInString(line); n > longest {
+			longest = n
+		}
+	}
+	width := math.Ceil(float64(longest) * float64(fontSize) * ratio)
+	height := math.Ceil(float64(len(lines)) * float64(fontSize) * lineHeightRatio)
+
+	extra := 0.0
+	if w.Stroke != nil {
+		extra += float64(w.Stroke.Width)
+	}
+	if w.Background != nil {
+		extra += float64(w.Background.Padding)
+	}
+	return int(width + 2*extra), int(height + 2*extra)
+}
+
+// IsRTL reports whether the text is laid out right-to-left, either because Direction is DirectionRTL
+// or because the first strong character of the text belongs to a right-to-left script.
+func (w WatermarkText) IsRTL() bool {
+	switch w.Direction {
+	case DirectionRTL:
+		return true
+	case DirectionLTR:
+		return false
+	}
+	for _, r := range w.Text {
+		switch {
+		case unicode.In(r, unicode.Arabic, unicode.Hebrew, unicode.Syriac, unicode.Thaana, unicode.Nko):
+			return true
+		case unicode.IsLetter(r):
+			return false
+		}
+	}
+	return false
+}
+
+/*
+WithAutoColor returns a copy of the Watermark with FontColor and Stroke chosen to stay readable on sample,
+typically the region of a frame that the Watermark covers (see WatermarkRect).
+
+FontColor is the color of the palette with the highest contrast against the average color of sample.
+colors.DefaultPalette is used if palette is empty, and an error is returned if no color of palette is valid.
+The outline is black or white, whichever has the highest contrast against FontColor, so that it stays visible
+with a single color palette. The width of an existing Stroke is kept.
+*/
+func (w WatermarkText) WithAutoColor(sample image.Image, palette ...colors.Color) (WatermarkText, error) {
+	font := colors.MostReadable(colors.Average(sample), palette...)
+	fill, err := colors.Parse(font)
+	if err != nil {
+		return w, fmt.Errorf("no valid color in palette %v", palette)
+	}
+	w.FontColor = font
+
+	stroke := TextStroke{Width: 1}
+	if w.Stroke != nil {
+		stroke = *w.Stroke
+	}
+	if stroke.Width == 0 {
+		stroke.Width = 1
+	}
+	stroke.Color = colors.MostReadable(fill, colors.Black, colors.White)
+	w.Stroke = &stroke
+	return w, nil
+}
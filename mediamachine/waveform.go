@@ -0,0 +1,172 @@
+package mediamachine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/stackrock/mediamachinego/colors"
+)
+
+// WaveformFormat is the output format of a waveform
+type WaveformFormat = string
+
+const (
+	// WaveformFormatJSON outputs the peaks of the audio as JSON, in the format of the audiowaveform tool
+	// understood by common waveform players. See WaveformData
+	WaveformFormatJSON WaveformFormat = "json"
+	// WaveformFormatPNG outputs a rendered image of the waveform
+	WaveformFormatPNG WaveformFormat = "png"
+
+	// DefaultWaveformPointsPerSecond is the resolution of a JSON waveform when PointsPerSecond is not set
+	DefaultWaveformPointsPerSecond = 10
+	// MaxWaveformSize is the largest Width or Height in pixels of a PNG waveform
+	MaxWaveformSize = 8192
+)
+
+/*
+WaveformConfig configures the request for generating the waveform of the audio track of the input.
+
+Width and Height are required for WaveformFormatPNG, PointsPerSecond and Bits are only applicable to
+WaveformFormatJSON.
+*/
+type WaveformConfig struct {
+	Format WaveformFormat // Optional - defaults to WaveformFormatJSON
+	Track  uint           // Optional - index of the audio track of the input, defaults to the first one
+
+	PointsPerSecond uint // Optional - number of min/max pairs per second, defaults to DefaultWaveformPointsPerSecond
+	Bits            uint // Optional - resolution of the peaks, 8 or 16 (default)
+
+	Width           uint         // Width in pixels of the image
+	Height          uint         // Height in pixels of the image
+	Color           colors.Color // Optional - color of the waveform, defaults to black
+	BackgroundColor colors.Color // Optional - defaults to a transparent background
+	SplitChannels   bool         // Optional - draw one waveform per audio channel
+
+	// Structured as {http|https|s3|azure|gcp}://{bucket-name}/{prefix-if-any}/{object-name}
+	// Examples: s3://bucket/prefix/input.mp4, https://example.com/files/input.mp3
+	InputURL  string
+	OutputURL string
+
+	// Provide credentials to S3/Azure/GCP for input/output locations
+	// Can be nil if using http(s) input/output urls - make sure url endpoints are accessible
+	// Note: You can use a different set of creds for input and output if you want to upload to a totally different
+	// account for example or to a different bucket if you generate keys specific to bucket etc. or reuse the same
+	// Creds object. See examples folder for usage.
+	InputCreds  Creds
+	OutputCreds Creds
+
+	SuccessURL string // Optional - Expect a POST call when job is successfully finished
+	FailureURL string // Optional - Expect a POST call with failure details
+}
+
+/*
+Waveform enqueues a request to the MediaMachine backend to asynchronously generate the waveform of the input audio or video.
+
+The output is uploaded to the location specified in the WaveformConfig.
+Errors if the input configuration is invalid.
+*/
+func (m MediaMachine) Waveform(cfg WaveformConfig) (Job, error) {
+	if err := validateInputOutput(cfg.InputURL, cfg.OutputURL, cfg.InputCreds, cfg.OutputCreds); err != nil {
+		return Job{}, err
+	}
+	if err := cfg.validate(); err != nil {
+		return Job{}, err
+	}
+
+	wr := struct {
+		APIKey string
+		WaveformConfig
+	}{
+		APIKey:         m.APIKey,
+		WaveformConfig: cfg,
+	}
+
+	body, err := json.Marshal(wr)
+	if err != nil {
+		return Job{}, err
+	}
+	return m.submit("/audio/waveform", bytes.NewBuffer(body))
+}
+
+func (cfg WaveformConfig) validate() error {
+	switch cfg.Format {
+	case "", WaveformFormatJSON:
+		if cfg.Width != 0 || cfg.Height != 0 || cfg.Color != "" || cfg.BackgroundColor != "" || cfg.SplitChannels {
+			return fmt.Errorf("waveform image settings are only applicable to format '%s'", WaveformFormatPNG)
+		}
+		if cfg.Bits != 0 && cfg.Bits != 8 && cfg.Bits != 16 {
+			return fmt.Errorf("waveform Bits must be 8 or 16")
+		}
+	case WaveformFormatPNG:
+		if cfg.PointsPerSecond != 0 || cfg.Bits != 0 {
+			return fmt.Errorf("waveform PointsPerSecond and Bits are only applicable to format '%s'", WaveformFormatJSON)
+		}
+		if cfg.Width == 0 || cfg.Height == 0 {
+			return fmt.Errorf("waveform Width and Height must be set for format '%s'", WaveformFormatPNG)
+		}
+		if cfg.Width > MaxWaveformSize || cfg.Height > MaxWaveformSize {
+			return fmt.Errorf("waveform image cannot be larger than %dx%d", MaxWaveformSize, MaxWaveformSize)
+		}
+		if cfg.Color != "" && !colors.Valid(cfg.Color) {
+			return fmt.Errorf("invalid waveform Color: '%s'", cfg.Color)
+		}
+		if cfg.BackgroundColor != "" && !colors.Valid(cfg.BackgroundColor) {
+			return fmt.Errorf("invalid waveform BackgroundColor: '%s'", cfg.BackgroundColor)
+		}
+	default:
+		return fmt.Errorf("unsupported waveform Format: '%s'", cfg.Format)
+	}
+	return nil
+}
+
+/*
+WaveformData is a JSON waveform, in the format of the audiowaveform tool.
+
+Data holds a min/max pair of samples for every SamplesPerPixel samples of the input, interleaved per channel:
+min and max of channel 0, then of channel 1, and so on.
+*/
+type WaveformData struct {
+	Version         int   `json:"version"`
+	Channels        int   `json:"channels"`
+	SampleRate      int   `json:"sample_rate"`
+	SamplesPerPixel int   `json:"samples_per_pixel"`
+	Bits            int   `json:"bits"`
+	Length          int   `json:"length"` // Number of min/max pairs per channel
+	Data            []int `json:"data"`
+}
+
+// ParseWaveform reads a JSON waveform and checks that its data is consistent with its header.
+func ParseWaveform(r io.Reader) (WaveformData, error) {
+	var w WaveformData
+	if err := json.NewDecoder(r).Decode(&w); err != nil {
+		return WaveformData{}, fmt.Errorf("invalid waveform: %s", err)
+	}
+	if w.Channels == 0 {
+		// version 1 files have no channels field and are always mono
+		w.Channels = 1
+	}
+	if w.Bits != 8 && w.Bits != 16 {
+		return WaveformData{}, fmt.Errorf("invalid waveform: unsupported bits %d", w.Bits)
+	}
+	if len(w.Data) != 2*w.Length*w.Channels {
+		return WaveformData{}, fmt.Errorf("invalid waveform: expected %d values, got %d", 2*w.Length*w.Channels, len(w.Data))
+	}
+	return w, nil
+}
+
+// Peaks returns the min/max pairs of a channel, scaled between -1 and 1.
+func (w WaveformData) Peaks(channel int) ([]float64, []float64) {
+	if channel < 0 || channel >= w.Channels {
+		return nil, nil
+	}
+	scale := float64(int(1) << uint(w.Bits-1))
+	mins, maxs := make([]float64, w.Length), make([]float64, w.Length)
+	for i := 0; i < w.Length; i++ {
+		base := 2 * (i*w.Channels + channel)
+		mins[i] = float64(w.Data[base]) / scale
+		maxs[i] = float64(w.Data[base+1]) / scale
+	}
+	return mins, maxs
+}
@@ -0,0 +1,467 @@
+package probe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/stackrock/mediamachinego/mediamachine"
+)
+
+// maxBoxDataSize bounds the size of the boxes read in memory, such as the sample description box
+const maxBoxDataSize = 1 << 20
+
+// mp4Codecs maps the sample entry formats of MP4 tracks to codec names
+var mp4Codecs = map[string]string{
+	"avc1": "h264", "avc3": "h264",
+	"hvc1": "hevc", "hev1": "hevc", "dvh1": "hevc", "dvhe": "hevc",
+	"vp08": "vp8", "vp09": "vp9",
+	"av01": "av1",
+	"apch": "prores", "apcn": "prores", "apcs": "prores", "apco": "prores", "ap4h": "prores", "ap4x": "prores",
+	"mp4a": "aac",
+	"Opus": "opus",
+	".mp3": "mp3",
+	"ac-3": "ac3",
+	"ec-3": "eac3",
+	"fLaC": "flac",
+	"sowt": "pcm", "twos": "pcm", "lpcm": "pcm", "ipcm": "pcm",
+}
+
+type box struct {
+	typ       string
+	dataStart int64
+	end       int64
+}
+
+// boxes lists the boxes stored between start and end.
+// Listing stops after a box of type last, so that the data after it does not need to be valid or complete.
+func (r reader) boxes(start, end int64, last string) ([]box, error) {
+	var boxes []box
+	for off := start; end-off >= 8; {
+		hdr, err := r.read(off, 8)
+		if err != nil {
+			return nil, err
+		}
+		size, hdrLen := int64(binary.BigEndian.Uint32(hdr)), int64(8)
+		typ := string(hdr[4:8])
+		switch size {
+		case 0:
+			size = end - off
+		case 1:
+			large, err := r.read(off+8, 8)
+			if err != nil {
+				return nil, err
+			}
+			size, hdrLen = int64(binary.BigEndian.Uint64(large)), 16
+		}
+		if size < hdrLen || size > end-off {
+			return nil, fmt.Errorf("invalid size for box '%s'", typ)
+		}
+		boxes = append(boxes, box{typ: typ, dataStart: off + hdrLen, end: off + size})
+		if typ == last {
+			break
+		}
+		off += size
+	}
+	return boxes, nil
+}
+
+func (r reader) children(parent box) ([]box, error) {
+	return r.boxes(parent.dataStart, parent.end, "")
+}
+
+func (r reader) data(b box) ([]byte, error) {
+	if b.end-b.dataStart > maxBoxDataSize {
+		return nil, fmt.Errorf("%s box is too large", b.typ)
+	}
+	return r.read(b.dataStart, b.end-b.dataStart)
+}
+
+func find(boxes []box, typ string) (box, bool) {
+	for _, b := range boxes {
+		if b.typ == typ {
+			return b, true
+		}
+	}
+	return box{}, false
+}
+
+func readMP4(r reader) (mediamachine.MediaInfo, error) {
+	// the boxes after moov are media data, that may be truncated in partial downloads
+	top, err := r.boxes(0, r.size, "moov")
+	if err != nil {
+		return mediamachine.MediaInfo{}, err
+	}
+
+	info := mediamachine.MediaInfo{Container: "mov"}
+	if ftyp, ok := find(top, "ftyp"); ok {
+		data, err := r.read(ftyp.dataStart, 4)
+		if err != nil {
+			return mediamachine.MediaInfo{}, err
+		}
+		if string(data) != "qt  " {
+			info.Container = "mp4"
+		}
+	}
+	moov, ok := find(top, "moov")
+	if !ok {
+		return mediamachine.MediaInfo{}, fmt.Errorf("no moov box found")
+	}
+	boxes, err := r.children(moov)
+	if err != nil {
+		return mediamachine.MediaInfo{}, err
+	}
+
+	index := 0
+	for _, b := range boxes {
+		switch b.typ {
+		case "mvhd":
+			data, err := r.data(b)
+			if err != nil {
+				return mediamachine.MediaInfo{}, err
+			}
+			timescale, duration, err := parseTimes(data, 12, 20)
+			if err != nil {
+				return mediamachine.MediaInfo{}, fmt.Errorf("invalid mvhd box: %s", err)
+			}
+			info.Duration = scaledDuration(duration, timescale)
+		case "trak":
+			if err := r.readTrak(b, index, &info); err != nil {
+				return mediamachine.MediaInfo{}, fmt.Errorf("track %d: %s", index, err)
+			}
+			index++
+		}
+	}
+	return info, nil
+}
+
+// parseTimes reads the timescale and duration of an mvhd or mdhd box, at the given offsets for version 0 and 1
+func parseTimes(data []byte, v0Offset, v1Offset int) (uint64, uint64, error) {
+	if len(data) < 4 {
+		return 0, 0, fmt.Errorf("box is too short")
+	}
+	if data[0] == 1 {
+		if len(data) < v1Offset+12 {
+			return 0, 0, fmt.Errorf("box is too short")
+		}
+		duration := binary.BigEndian.Uint64(data[v1Offset+4:])
+		if duration == math.MaxUint64 {
+			duration = 0
+		}
+		return uint64(binary.BigEndian.Uint32(data[v1Offset:])), duration, nil
+	}
+	if len(data) < v0Offset+8 {
+		return 0, 0, fmt.Errorf("box is too short")
+	}
+	duration := uint64(binary.BigEndian.Uint32(data[v0Offset+4:]))
+	if duration == math.MaxUint32 {
+		duration = 0
+	}
+	return uint64(binary.BigEndian.Uint32(data[v0Offset:])), duration, nil
+}
+
+type mp4Track struct {
+	handler   string
+	timescale uint64
+	duration  uint64
+	language  string
+	samples   uint32
+	rotation  int
+}
+
+func (r reader) readTrak(trak box, index int, info *mediamachine.MediaInfo) error {
+	boxes, err := r.children(trak)
+	if err != nil {
+		return err
+	}
+	var t mp4Track
+	if tkhd, ok := find(boxes, "tkhd"); ok {
+		data, err := r.data(tkhd)
+		if err != nil {
+			return err
+		}
+		if t.rotation, err = parseRotation(data); err != nil {
+			return err
+		}
+	}
+	mdia, ok := find(boxes, "mdia")
+	if !ok {
+		return fmt.Errorf("no mdia box found")
+	}
+	if boxes, err = r.children(mdia); err != nil {
+		return err
+	}
+
+	var stsd box
+	for _, b := range boxes {
+		switch b.typ {
+		case "mdhd":
+			data, err := r.data(b)
+			if err != nil {
+				return err
+			}
+			if t.timescale, t.duration, err = parseTimes(data, 12, 20); err != nil {
+				return fmt.Errorf("invalid mdhd box: %s", err)
+			}
+			langOffset := 20
+			if data[0] == 1 {
+				langOffset = 32
+			}
+			if len(data) >= langOffset+2 {
+				t.language = parseLanguage(binary.BigEndian.Uint16(data[langOffset:]))
+			}
+		case "hdlr":
+			data, err := r.data(b)
+			if err != nil {
+				return err
+			}
+			if len(data) < 12 {
+				return fmt.Errorf("invalid hdlr box")
+			}
+			t.handler = string(data[8:12])
+		case "minf":
+			stbl, err := r.childPath(b, "stbl")
+			if err != nil {
+				return err
+			}
+			if stsd, err = r.childPath(stbl, "stsd"); err != nil {
+				return err
+			}
+			if stsz, err := r.childPath(stbl, "stsz"); err == nil {
+				if stsz.end-stsz.dataStart < 12 {
+					return fmt.Errorf("invalid stsz box")
+				}
+				data, err := r.read(stsz.dataStart, 12)
+				if err != nil {
+					return err
+				}
+				t.samples = binary.BigEndian.Uint32(data[8:])
+			}
+		}
+	}
+
+	switch t.handler {
+	case "vide":
+		track, err := r.readVideoEntry(stsd, t)
+		if err != nil {
+			return err
+		}
+		track.Index = index
+		info.VideoTracks = append(info.VideoTracks, track)
+	case "soun":
+		track, err := r.readAudioEntry(stsd, t)
+		if err != nil {
+			return err
+		}
+		track.Index = index
+		info.AudioTracks = append(info.AudioTracks, track)
+	}
+	return nil
+}
+
+func (r reader) childPath(parent box, typ string) (box, error) {
+	boxes, err := r.children(parent)
+	if err != nil {
+		return box{}, err
+	}
+	b, ok := find(boxes, typ)
+	if !ok {
+		return box{}, fmt.Errorf("no %s box found", typ)
+	}
+	return b, nil
+}
+
+// parseRotation reads the clockwise rotation of the transformation matrix of a tkhd box
+func parseRotation(tkhd []byte) (int, error) {
+	matrix := 40
+	if len(tkhd) > 0 && tkhd[0] == 1 {
+		matrix = 52
+	}
+	if len(tkhd) < matrix+36 {
+		return 0, fmt.Errorf("invalid tkhd box")
+	}
+	a := float64(int32(binary.BigEndian.Uint32(tkhd[matrix:])))
+	b := float64(int32(binary.BigEndian.Uint32(tkhd[matrix+4:])))
+	degrees := int(math.Round(math.Atan2(b, a)*180/math.Pi/90)) * 90
+	return (degrees + 360) % 360, nil
+}
+
+// parseLanguage decodes the packed ISO 639-2/T language code of an mdhd box
+func parseLanguage(packed uint16) string {
+	lang := string([]byte{
+		byte(packed>>10&0x1f) + 0x60,
+		byte(packed>>5&0x1f) + 0x60,
+		byte(packed&0x1f) + 0x60,
+	})
+	if lang == "und" || strings.ContainsAny(lang, "`\x7f") || packed == 0 {
+		return ""
+	}
+	return lang
+}
+
+// sampleEntry returns the first sample entry of an stsd box, and its format
+func (r reader) sampleEntry(stsd box) (box, []byte, error) {
+	if stsd.end-stsd.dataStart > maxBoxDataSize {
+		return box{}, nil, fmt.Errorf("stsd box is too large")
+	}
+	entries, err := r.boxes(stsd.dataStart+8, stsd.end, "")
+	if err != nil {
+		return box{}, nil, err
+	}
+	if len(entries) == 0 {
+		return box{}, nil, fmt.Errorf("no sample entry found")
+	}
+	data, err := r.data(entries[0])
+	return entries[0], data, err
+}
+
+func (r reader) readVideoEntry(stsd box, t mp4Track) (mediamachine.VideoTrack, error) {
+	entry, data, err := r.sampleEntry(stsd)
+	if err != nil {
+		return mediamachine.VideoTrack{}, err
+	}
+	if len(data) < 78 {
+		return mediamachine.VideoTrack{}, fmt.Errorf("invalid video sample entry")
+	}
+	track := mediamachine.VideoTrack{
+		Codec:    codecName(entry.typ),
+		Width:    uint(binary.BigEndian.Uint16(data[24:])),
+		Height:   uint(binary.BigEndian.Uint16(data[26:])),
+		Rotation: t.rotation,
+	}
+	if t.duration > 0 && t.timescale > 0 {
+		fps := float64(t.samples) / (float64(t.duration) / float64(t.timescale))
+		track.FrameRate = math.Round(fps*1000) / 1000
+	}
+	if entry.typ == "dvh1" || entry.typ == "dvhe" {
+		track.HDR = mediamachine.HDRDolbyVision
+	}
+
+	children, err := r.boxes(entry.dataStart+78, entry.end, "")
+	if err != nil {
+		return mediamachine.VideoTrack{}, err
+	}
+	for _, c := range children {
+		switch c.typ {
+		case "colr":
+			colr, err := r.data(c)
+			if err != nil {
+				return mediamachine.VideoTrack{}, err
+			}
+			if len(colr) >= 8 && (string(colr[:4]) == "nclx" || string(colr[:4]) == "nclc") {
+				primaries := uint64(binary.BigEndian.Uint16(colr[4:]))
+				transfer := uint64(binary.BigEndian.Uint16(colr[6:]))
+				track.ColorPrimaries = colorPrimaries[primaries]
+				track.TransferCharacteristics = transferCharacteristics[transfer]
+				if track.HDR == mediamachine.HDRNone {
+					track.HDR = hdrFormat(transfer)
+				}
+			}
+		case "dvcC", "dvvC":
+			track.HDR = mediamachine.HDRDolbyVision
+		case "avcC", "hvcC", "vpcC", "av1C":
+			config, err := r.data(c)
+			if err != nil {
+				return mediamachine.VideoTrack{}, err
+			}
+			track.BitDepth = codecBitDepth(c.typ, config)
+		}
+	}
+	return track, nil
+}
+
+func (r reader) readAudioEntry(stsd box, t mp4Track) (mediamachine.AudioTrack, error) {
+	entry, data, err := r.sampleEntry(stsd)
+	if err != nil {
+		return mediamachine.AudioTrack{}, err
+	}
+	if len(data) < 28 {
+		return mediamachine.AudioTrack{}, fmt.Errorf("invalid audio sample entry")
+	}
+	track := mediamachine.AudioTrack{
+		Codec:    codecName(entry.typ),
+		Language: t.language,
+	}
+	if version := binary.BigEndian.Uint16(data[8:]); version == 2 {
+		// QuickTime sound sample description version 2
+		if len(data) < 44 {
+			return mediamachine.AudioTrack{}, fmt.Errorf("invalid audio sample entry")
+		}
+		track.SampleRate = uint(math.Float64frombits(binary.BigEndian.Uint64(data[32:])))
+		track.Channels = uint(binary.BigEndian.Uint32(data[40:]))
+	} else {
+		track.Channels = uint(binary.BigEndian.Uint16(data[16:]))
+		track.SampleRate = uint(binary.BigEndian.Uint16(data[24:]))
+	}
+	return track, nil
+}
+
+// codecBitDepth reads the bit depth of the samples from the codec configuration box of a video sample entry,
+// 0 if it is not known
+func codecBitDepth(typ string, config []byte) uint {
+	switch typ {
+	case "avcC":
+		return avcBitDepth(config)
+	case "hvcC":
+		if len(config) >= 18 {
+			return uint(config[17]&0x07) + 8
+		}
+	case "vpcC":
+		// version and flags, profile, level, then bitDepth in the high 4 bits
+		if len(config) >= 7 {
+			return uint(config[6] >> 4)
+		}
+	case "av1C":
+		if len(config) >= 3 {
+			highBitDepth, twelveBit := config[2]&0x40 != 0, config[2]&0x20 != 0
+			switch {
+			case highBitDepth && twelveBit:
+				return 12
+			case highBitDepth:
+				return 10
+			}
+			return 8
+		}
+	}
+	return 0
+}
+
+// avcBitDepth reads the bit depth of an avcC box, stored after the parameter sets for the high profiles
+func avcBitDepth(config []byte) uint {
+	if len(config) < 6 {
+		return 0
+	}
+	switch config[1] {
+	case 66, 77, 88, 100:
+		// baseline, main, extended and high profiles only use 8 bit samples
+		return 8
+	}
+
+	off := 6
+	skipSets := func(count int) bool {
+		for ; count > 0; count-- {
+			if len(config) < off+2 {
+				return false
+			}
+			off += 2 + int(binary.BigEndian.Uint16(config[off:]))
+		}
+		return true
+	}
+	if !skipSets(int(config[5]&0x1f)) || len(config) < off+1 {
+		return 0
+	}
+	pps := int(config[off])
+	off++
+	if !skipSets(pps) || len(config) < off+2 {
+		return 0
+	}
+	return uint(config[off+1]&0x07) + 8
+}
+
+func codecName(format string) string {
+	if name, ok := mp4Codecs[format]; ok {
+		return name
+	}
+	return strings.TrimSpace(format)
+}
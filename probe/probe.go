@@ -0,0 +1,123 @@
+/*
+Package probe reads the metadata of MP4, MOV, WebM and Matroska files locally, without any external binary.
+
+Only the headers are read: the moov box of MP4/MOV files and the Info and Tracks elements of WebM/Matroska files.
+Reading stops once they are found, so partial downloads and files with trailing data are accepted as long as the
+headers are complete. Any io.ReaderAt can be used, e.g. an *os.File or a reader issuing HTTP range requests, so that
+large files are not downloaded. The result can be checked against an operation with TranscodeConfig.CheckInput or
+ThumbnailConfig.CheckInput, to reject invalid inputs before a job is submitted.
+
+HDR formats are detected from the color information of the tracks, and Dolby Vision from its MP4 configuration
+boxes. BitDepth is read from the codec configuration box of MP4 tracks (avcC, hvcC, vpcC or av1C) and from the
+Colour element of Matroska tracks, when they store it. Per-track bitrates, codec profiles and PixelFormat are not read.
+*/
+package probe
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/stackrock/mediamachinego/mediamachine"
+)
+
+var ebmlMagic = []byte{0x1a, 0x45, 0xdf, 0xa3}
+
+// mp4FirstBoxes lists the box types that can start an MP4 or MOV file
+var mp4FirstBoxes = map[string]bool{
+	"ftyp": true, "moov": true, "mdat": true, "free": true, "skip": true, "wide": true, "pnot": true,
+}
+
+/*
+Read reads the metadata of the MP4, MOV, WebM or Matroska file of the given size.
+
+Errors if the format is not supported, or if the headers are invalid or truncated.
+*/
+func Read(r io.ReaderAt, size int64) (mediamachine.MediaInfo, error) {
+	src := reader{r: r, size: size}
+	head, err := src.read(0, 8)
+	if err != nil {
+		return mediamachine.MediaInfo{}, fmt.Errorf("probe: file is too short to be a media file")
+	}
+
+	var info mediamachine.MediaInfo
+	switch {
+	case string(head[:4]) == string(ebmlMagic):
+		info, err = readWebM(src)
+	case mp4FirstBoxes[string(head[4:8])]:
+		info, err = readMP4(src)
+	default:
+		return mediamachine.MediaInfo{}, fmt.Errorf("probe: unsupported file format, expected MP4, MOV, WebM or Matroska")
+	}
+	if err != nil {
+		return mediamachine.MediaInfo{}, fmt.Errorf("probe: %s", err)
+	}
+
+	info.SizeBytes = size
+	if info.Duration > 0 {
+		info.BitrateKBPS = uint(float64(size) * 8 / info.Duration.Seconds() / 1000)
+	}
+	return info, nil
+}
+
+// File reads the metadata of the media file at path. See Read.
+func File(path string) (mediamachine.MediaInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return mediamachine.MediaInfo{}, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return mediamachine.MediaInfo{}, err
+	}
+	return Read(f, stat.Size())
+}
+
+// reader reads bounded ranges of a file
+type reader struct {
+	r    io.ReaderAt
+	size int64
+}
+
+func (r reader) read(off, n int64) ([]byte, error) {
+	if off < 0 || n < 0 || off+n > r.size {
+		return nil, fmt.Errorf("file is truncated")
+	}
+	buf := make([]byte, n)
+	read, err := r.r.ReadAt(buf, off)
+	if int64(read) == n {
+		return buf, nil
+	}
+	if err == nil || err == io.EOF {
+		err = fmt.Errorf("file is truncated")
+	}
+	return nil, err
+}
+
+// scaledDuration converts a duration expressed in units of 1/timescale seconds
+func scaledDuration(value, timescale uint64) time.Duration {
+	if timescale == 0 {
+		return 0
+	}
+	return time.Duration(float64(value) / float64(timescale) * float64(time.Second))
+}
+
+// hdrFormat maps the ISO/IEC 23091-2 transfer characteristics of a track to its HDR format
+func hdrFormat(transfer uint64) mediamachine.HDRFormat {
+	switch transfer {
+	case 16:
+		return mediamachine.HDR10
+	case 18:
+		return mediamachine.HDRHLG
+	}
+	return mediamachine.HDRNone
+}
+
+var colorPrimaries = map[uint64]string{1: "bt709", 5: "bt470bg", 6: "smpte170m", 9: "bt2020", 12: "smpte432"}
+
+var transferCharacteristics = map[uint64]string{
+	1: "bt709", 6: "smpte170m", 13: "iec61966-2-1", 14: "bt2020-10", 15: "bt2020-12", 16: "smpte2084", 18: "arib-std-b67",
+}
@@ -0,0 +1,301 @@
+package probe_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/stackrock/mediamachinego/mediamachine"
+	"github.com/stackrock/mediamachinego/probe"
+)
+
+func be16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return b
+}
+
+func be32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func be64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+func zeros(n int) []byte { return make([]byte, n) }
+
+func mp4Box(typ string, payload ...[]byte) []byte {
+	data := bytes.Join(payload, nil)
+	return append(append(be32(uint32(8+len(data))), typ...), data...)
+}
+
+func tkhd(a, b, c, d int32) []byte {
+	var matrix []byte
+	for _, v := range []int32{a, b, 0, c, d, 0, 0, 0, 0x40000000} {
+		matrix = append(matrix, be32(uint32(v))...)
+	}
+	return mp4Box("tkhd", zeros(40), matrix, be32(1920<<16), be32(1080<<16))
+}
+
+func mdhd(timescale, duration uint32, lang string) []byte {
+	packed := uint16(lang[0]-0x60)<<10 | uint16(lang[1]-0x60)<<5 | uint16(lang[2]-0x60)
+	return mp4Box("mdhd", zeros(12), be32(timescale), be32(duration), be16(packed), zeros(2))
+}
+
+func trak(header, mdhd []byte, handler string, entry []byte, samples uint32) []byte {
+	stbl := mp4Box("stbl",
+		mp4Box("stsd", zeros(4), be32(1), entry),
+		mp4Box("stsz", zeros(8), be32(samples)),
+	)
+	return mp4Box("trak", header, mp4Box("mdia",
+		mdhd,
+		mp4Box("hdlr", zeros(8), []byte(handler), zeros(13)),
+		mp4Box("minf", stbl),
+	))
+}
+
+func sampleMP4() []byte {
+	video := mp4Box("avc1", zeros(24), be16(1920), be16(1080), zeros(50),
+		mp4Box("avcC", []byte{1, 100, 0, 40, 0xff, 0xe0, 0}),
+		mp4Box("colr", []byte("nclx"), be16(9), be16(16), be16(9), []byte{0}))
+	audio := mp4Box("mp4a", zeros(16), be16(2), be16(16), zeros(4), be32(48000<<16))
+	return bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("isom"), be32(0x200), []byte("isomavc1")),
+		mp4Box("moov",
+			mp4Box("mvhd", zeros(12), be32(1000), be32(10000), zeros(80)),
+			trak(tkhd(0, 0x10000, -0x10000, 0), mdhd(90000, 900000, "und"), "vide", video, 300),
+			trak(tkhd(0x10000, 0, 0, 0x10000), mdhd(48000, 480000, "eng"), "soun", audio, 469),
+		),
+		mp4Box("mdat", zeros(1000)),
+	}, nil)
+}
+
+func ebml(id uint32, payload ...[]byte) []byte {
+	idBytes := bytes.TrimLeft(be32(id), "\x00")
+	data := bytes.Join(payload, nil)
+	size := be64(uint64(len(data)))
+	size[0] = 0x01
+	return append(append(idBytes, size...), data...)
+}
+
+func ebmlUint(id uint32, v uint64) []byte {
+	return ebml(id, be64(v))
+}
+
+func ebmlFloat(id uint32, v float64) []byte {
+	return ebml(id, be64(math.Float64bits(v)))
+}
+
+func webmSegment() []byte {
+	return bytes.Join([][]byte{
+		ebml(0x1549a966, ebmlUint(0x2ad7b1, 1000000), ebmlFloat(0x4489, 5000)),
+		ebml(0x1654ae6b,
+			ebml(0xae,
+				ebmlUint(0x83, 1),
+				ebml(0x86, []byte("V_VP9")),
+				ebmlUint(0x23e383, 33333333),
+				ebml(0xe0, ebmlUint(0xb0, 1280), ebmlUint(0xba, 720),
+					ebml(0x55b0, ebmlUint(0x55b2, 10), ebmlUint(0x55ba, 18), ebmlUint(0x55bb, 9))),
+			),
+			ebml(0xae,
+				ebmlUint(0x83, 2),
+				ebml(0x86, []byte("A_OPUS")),
+				ebml(0x22b59c, []byte("fre")),
+				ebml(0xe1, ebmlFloat(0xb5, 48000), ebmlUint(0x9f, 2)),
+			),
+		),
+		ebml(0x1f43b675, zeros(100)),
+	}, nil)
+}
+
+func sampleWebM() []byte {
+	return bytes.Join([][]byte{
+		ebml(0x1a45dfa3, ebml(0x4282, []byte("webm"))),
+		// a live recording has a Segment of unknown size
+		{0x18, 0x53, 0x80, 0x67, 0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		webmSegment(),
+	}, nil)
+}
+
+var _ = Describe("Read", func() {
+	It("reads the moov box of MP4 files", func() {
+		data := sampleMP4()
+		info, err := probe.Read(bytes.NewReader(data), int64(len(data)))
+		Expect(err).To(BeNil())
+		Expect(info.Container).To(Equal("mp4"))
+		Expect(info.Duration).To(Equal(10 * time.Second))
+		Expect(info.SizeBytes).To(Equal(int64(len(data))))
+		Expect(info.VideoTracks).To(Equal([]mediamachine.VideoTrack{{
+			Index:                   0,
+			Codec:                   "h264",
+			Width:                   1920,
+			Height:                  1080,
+			FrameRate:               30,
+			Rotation:                90,
+			BitDepth:                8,
+			ColorPrimaries:          "bt2020",
+			TransferCharacteristics: "smpte2084",
+			HDR:                     mediamachine.HDR10,
+		}}))
+		Expect(info.AudioTracks).To(Equal([]mediamachine.AudioTrack{{
+			Index:      1,
+			Codec:      "aac",
+			Channels:   2,
+			SampleRate: 48000,
+			Language:   "eng",
+		}}))
+		Expect(info.DisplaySize()).To(Equal(mediamachine.Size{Width: 1080, Height: 1920}))
+	})
+
+	It("reads the headers of WebM files", func() {
+		data := sampleWebM()
+		info, err := probe.Read(bytes.NewReader(data), int64(len(data)))
+		Expect(err).To(BeNil())
+		Expect(info.Container).To(Equal("webm"))
+		Expect(info.Duration).To(Equal(5 * time.Second))
+		Expect(info.VideoTracks).To(Equal([]mediamachine.VideoTrack{{
+			Codec:                   "vp9",
+			Width:                   1280,
+			Height:                  720,
+			FrameRate:               30,
+			BitDepth:                10,
+			ColorPrimaries:          "bt2020",
+			TransferCharacteristics: "arib-std-b67",
+			HDR:                     mediamachine.HDRHLG,
+		}}))
+		Expect(info.AudioTracks).To(Equal([]mediamachine.AudioTrack{{
+			Index:      1,
+			Codec:      "opus",
+			Channels:   2,
+			SampleRate: 48000,
+			Language:   "fre",
+		}}))
+	})
+
+	It("rejects unknown and truncated files", func() {
+		_, err := probe.Read(bytes.NewReader([]byte("GIF89a\x00\x00\x00")), 9)
+		Expect(err).To(MatchError(ContainSubstring("unsupported file format")))
+
+		data := sampleMP4()
+		_, err = probe.Read(bytes.NewReader(data), 100)
+		Expect(err).NotTo(BeNil())
+
+		data = mp4Box("ftyp", []byte("isom"), be32(0))
+		_, err = probe.Read(bytes.NewReader(data), int64(len(data)))
+		Expect(err).To(MatchError(ContainSubstring("moov")))
+
+		data = ebml(0x1a45dfa3, ebml(0x4282, []byte("webm")))
+		_, err = probe.Read(bytes.NewReader(data), int64(len(data)))
+		Expect(err).To(MatchError(ContainSubstring("Segment")))
+	})
+})
+
+var _ = Describe("Read of partial and malformed files", func() {
+	read := func(data []byte) (mediamachine.MediaInfo, error) {
+		return probe.Read(bytes.NewReader(data), int64(len(data)))
+	}
+	moov := func(video []byte) []byte {
+		return mp4Box("moov",
+			mp4Box("mvhd", zeros(12), be32(1000), be32(10000), zeros(80)),
+			trak(tkhd(0x10000, 0, 0, 0x10000), mdhd(90000, 900000, "und"), "vide", video, 300),
+		)
+	}
+
+	It("stops reading MP4 files after the moov box", func() {
+		data := sampleMP4()
+		_, err := read(append(data, "trailing garbage"...))
+		Expect(err).To(BeNil())
+
+		data = append(moov(mp4Box("vp09", zeros(78))), mp4Box("mdat", zeros(1000))...)
+		_, err = read(data[:len(data)-500])
+		Expect(err).To(BeNil())
+	})
+
+	It("reads the bit depth of MP4 codec configurations", func() {
+		vp9 := mp4Box("vp09", zeros(24), be16(3840), be16(2160), zeros(50), mp4Box("vpcC", be32(0x01000000), []byte{2, 10, 0xa2}))
+		info, err := read(moov(vp9))
+		Expect(err).To(BeNil())
+		Expect(info.VideoTracks[0].BitDepth).To(Equal(uint(10)))
+
+		av1 := mp4Box("av01", zeros(78), mp4Box("av1C", []byte{0x81, 0x08, 0x60, 0}))
+		info, err = read(moov(av1))
+		Expect(err).To(BeNil())
+		Expect(info.VideoTracks[0].BitDepth).To(Equal(uint(12)))
+	})
+
+	It("bounds the MP4 boxes read in memory", func() {
+		data := mp4Box("moov", mp4Box("mvhd", zeros(12), be32(1000), be32(10000), zeros(2<<20)))
+		_, err := read(data)
+		Expect(err).To(MatchError(ContainSubstring("mvhd box is too large")))
+
+		data = mp4Box("moov", mp4Box("trak", mp4Box("mdia",
+			mp4Box("minf", mp4Box("stbl", mp4Box("stsd", zeros(4), be32(0)), mp4Box("stsz", zeros(4)))))))
+		_, err = read(data)
+		Expect(err).To(MatchError(ContainSubstring("invalid stsz box")))
+	})
+
+	It("stops reading WebM files after the Segment", func() {
+		data := append(ebml(0x1a45dfa3, ebml(0x4282, []byte("webm"))), ebml(0x18538067, webmSegment())...)
+		_, err := read(append(data, "trailing garbage"...))
+		Expect(err).To(BeNil())
+
+		// truncated in the Cluster
+		_, err = read(data[:len(data)-50])
+		Expect(err).To(BeNil())
+	})
+
+	It("rejects WebM children running past their parent", func() {
+		data := bytes.Join([][]byte{
+			ebml(0x1a45dfa3, ebml(0x4282, []byte("webm"))),
+			ebml(0x18538067,
+				// the header of the TrackEntry does not fit in Tracks
+				ebml(0x1654ae6b, []byte{0xae}),
+				ebml(0x1549a966, ebmlUint(0x2ad7b1, 1000000)),
+			),
+		}, nil)
+		_, err := read(data)
+		Expect(err).To(MatchError(ContainSubstring("runs past its parent")))
+	})
+})
+
+var _ = Describe("CheckInput", func() {
+	data := sampleMP4()
+	info, _ := probe.Read(bytes.NewReader(data), int64(len(data)))
+
+	It("rejects configurations that do not match the input", func() {
+		cfg := mediamachine.TranscodeConfig{
+			Container: mediamachine.ContainerMP4,
+			Encoder:   mediamachine.EncoderH264,
+			Picture: mediamachine.Picture{
+				Rotate: mediamachine.RotateAuto,
+				Crop:   &mediamachine.Crop{Width: 1920, Height: 1080},
+			},
+		}
+		Expect(cfg.CheckInput(info)).NotTo(BeNil())
+		cfg.Crop = &mediamachine.Crop{Width: 1080, Height: 1080}
+		Expect(cfg.CheckInput(info)).To(BeNil())
+
+		thumbnail := mediamachine.ThumbnailConfig{
+			Mode:       mediamachine.ThumbnailModeTimestamps,
+			Timestamps: []time.Duration{5 * time.Second, 12 * time.Second},
+		}
+		Expect(thumbnail.CheckInput(info)).To(MatchError(ContainSubstring("after the end")))
+
+		thumbnail = mediamachine.ThumbnailConfig{
+			Selection: &mediamachine.ThumbnailSelection{SkipStart: info.Duration / 2, SkipEnd: info.Duration / 2},
+		}
+		Expect(thumbnail.CheckInput(info)).To(MatchError(ContainSubstring("leave no frame")))
+		thumbnail.Selection.SkipEnd = info.Duration / 4
+		Expect(thumbnail.CheckInput(info)).To(BeNil())
+		Expect(thumbnail.CheckInput(mediamachine.MediaInfo{})).To(MatchError(ContainSubstring("no video track")))
+	})
+})
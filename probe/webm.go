@@ -0,0 +1,414 @@
+package probe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"strings"
+	"time"
+
+	"github.com/stackrock/mediamachinego/mediamachine"
+)
+
+// EBML element IDs, see https://www.matroska.org/technical/elements.html
+const (
+	idEBML                    = 0x1a45dfa3
+	idDocType                 = 0x4282
+	idSegment                 = 0x18538067
+	idInfo                    = 0x1549a966
+	idTimestampScale          = 0x2ad7b1
+	idDuration                = 0x4489
+	idTracks                  = 0x1654ae6b
+	idTrackEntry              = 0xae
+	idTrackType               = 0x83
+	idCodecID                 = 0x86
+	idLanguage                = 0x22b59c
+	idLanguageBCP47           = 0x22b59d
+	idDefaultDuration         = 0x23e383
+	idVideo                   = 0xe0
+	idPixelWidth              = 0xb0
+	idPixelHeight             = 0xba
+	idColour                  = 0x55b0
+	idBitsPerChannel          = 0x55b2
+	idTransferCharacteristics = 0x55ba
+	idPrimaries               = 0x55bb
+	idAudio                   = 0xe1
+	idSamplingFrequency       = 0xb5
+	idChannels                = 0x9f
+	idCluster                 = 0x1f43b675
+
+	trackTypeVideo = 1
+	trackTypeAudio = 2
+
+	// maxLeafSize bounds the size of the values read in memory
+	maxLeafSize = 1024
+)
+
+// webmCodecs maps the codec IDs of Matroska tracks to codec names, by prefix
+var webmCodecs = []struct{ prefix, name string }{
+	{"V_VP8", "vp8"}, {"V_VP9", "vp9"}, {"V_AV1", "av1"},
+	{"V_MPEG4/ISO/AVC", "h264"}, {"V_MPEGH/ISO/HEVC", "hevc"}, {"V_PRORES", "prores"},
+	{"A_OPUS", "opus"}, {"A_VORBIS", "vorbis"}, {"A_AAC", "aac"}, {"A_MPEG/L3", "mp3"},
+	{"A_FLAC", "flac"}, {"A_AC3", "ac3"}, {"A_EAC3", "eac3"}, {"A_PCM", "pcm"},
+}
+
+type element struct {
+	id        uint64
+	dataStart int64
+	end       int64
+}
+
+// vint reads an EBML variable size integer, keeping its length marker for element IDs.
+// unknown is set for sizes with all their bits set.
+func (r reader) vint(off int64, keepMarker bool) (value uint64, length int64, unknown bool, err error) {
+	first, err := r.read(off, 1)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if first[0] == 0 {
+		return 0, 0, false, fmt.Errorf("invalid EBML integer at offset %d", off)
+	}
+	length = int64(bits.LeadingZeros8(first[0]) + 1)
+	data, err := r.read(off, length)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+	if keepMarker {
+		return value, length, false, nil
+	}
+	value &^= 1 << uint(7*length)
+	return value, length, value == 1<<uint(7*length)-1, nil
+}
+
+// elements lists the EBML elements stored between start and end.
+// Listing stops at a Cluster, as the elements needed are stored before the media data, and after an element
+// with ID last, which may then extend past end in truncated files.
+func (r reader) elements(start, end int64, last uint64) ([]element, error) {
+	var elements []element
+	for off := start; off < end; {
+		id, idLen, _, err := r.vint(off, true)
+		if err != nil {
+			return nil, err
+		}
+		size, sizeLen, unknown, err := r.vint(off+idLen, false)
+		if err != nil {
+			return nil, err
+		}
+		e := element{id: id, dataStart: off + idLen + sizeLen}
+		if id == idCluster {
+			break
+		}
+		if e.dataStart > end {
+			return nil, fmt.Errorf("EBML element 0x%x runs past its parent", id)
+		}
+		switch {
+		case unknown, id == last && size > uint64(end-e.dataStart):
+			e.end = end
+		case size > uint64(end-e.dataStart):
+			return nil, fmt.Errorf("invalid size for EBML element 0x%x", id)
+		default:
+			e.end = e.dataStart + int64(size)
+		}
+		elements = append(elements, e)
+		if id == last {
+			break
+		}
+		off = e.end
+	}
+	return elements, nil
+}
+
+func (r reader) subElements(parent element) ([]element, error) {
+	return r.elements(parent.dataStart, parent.end, 0)
+}
+
+func (r reader) leaf(e element) ([]byte, error) {
+	if e.end-e.dataStart > maxLeafSize {
+		return nil, fmt.Errorf("EBML element 0x%x is too large", e.id)
+	}
+	return r.read(e.dataStart, e.end-e.dataStart)
+}
+
+func (r reader) readUint(e element) (uint64, error) {
+	data, err := r.leaf(e)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) > 8 {
+		return 0, fmt.Errorf("invalid unsigned integer in EBML element 0x%x", e.id)
+	}
+	var v uint64
+	for _, b := range data {
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
+
+func (r reader) readFloat(e element) (float64, error) {
+	data, err := r.leaf(e)
+	if err != nil {
+		return 0, err
+	}
+	switch len(data) {
+	case 0:
+		return 0, nil
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), nil
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+	}
+	return 0, fmt.Errorf("invalid float in EBML element 0x%x", e.id)
+}
+
+func (r reader) readString(e element) (string, error) {
+	data, err := r.leaf(e)
+	return strings.TrimRight(string(data), "\x00"), err
+}
+
+func readWebM(r reader) (mediamachine.MediaInfo, error) {
+	// the media data in the Segment may be truncated in partial downloads, and followed by unrelated data
+	top, err := r.elements(0, r.size, idSegment)
+	if err != nil {
+		return mediamachine.MediaInfo{}, err
+	}
+	if len(top) == 0 || top[0].id != idEBML {
+		return mediamachine.MediaInfo{}, fmt.Errorf("no EBML header found")
+	}
+
+	info := mediamachine.MediaInfo{Container: "mkv"}
+	header, err := r.subElements(top[0])
+	if err != nil {
+		return mediamachine.MediaInfo{}, err
+	}
+	for _, e := range header {
+		if e.id == idDocType {
+			docType, err := r.readString(e)
+			if err != nil {
+				return mediamachine.MediaInfo{}, err
+			}
+			if docType == "webm" {
+				info.Container = "webm"
+			}
+		}
+	}
+
+	var segment *element
+	for i := range top {
+		if top[i].id == idSegment {
+			segment = &top[i]
+			break
+		}
+	}
+	if segment == nil {
+		return mediamachine.MediaInfo{}, fmt.Errorf("no Segment element found")
+	}
+	elements, err := r.subElements(*segment)
+	if err != nil {
+		return mediamachine.MediaInfo{}, err
+	}
+
+	foundTracks := false
+	for _, e := range elements {
+		switch e.id {
+		case idInfo:
+			if info.Duration, err = r.readSegmentInfo(e); err != nil {
+				return mediamachine.MediaInfo{}, err
+			}
+		case idTracks:
+			foundTracks = true
+			if err := r.readTracks(e, &info); err != nil {
+				return mediamachine.MediaInfo{}, err
+			}
+		}
+	}
+	if !foundTracks {
+		return mediamachine.MediaInfo{}, fmt.Errorf("no Tracks element found")
+	}
+	return info, nil
+}
+
+func (r reader) readSegmentInfo(info element) (time.Duration, error) {
+	elements, err := r.subElements(info)
+	if err != nil {
+		return 0, err
+	}
+	scale, duration := uint64(1000000), 0.0
+	for _, e := range elements {
+		switch e.id {
+		case idTimestampScale:
+			if scale, err = r.readUint(e); err != nil {
+				return 0, err
+			}
+		case idDuration:
+			if duration, err = r.readFloat(e); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return time.Duration(duration * float64(scale)), nil
+}
+
+func (r reader) readTracks(tracks element, info *mediamachine.MediaInfo) error {
+	entries, err := r.subElements(tracks)
+	if err != nil {
+		return err
+	}
+	index := 0
+	for _, entry := range entries {
+		if entry.id != idTrackEntry {
+			continue
+		}
+		if err := r.readTrackEntry(entry, index, info); err != nil {
+			return fmt.Errorf("track %d: %s", index, err)
+		}
+		index++
+	}
+	return nil
+}
+
+func (r reader) readTrackEntry(entry element, index int, info *mediamachine.MediaInfo) error {
+	elements, err := r.subElements(entry)
+	if err != nil {
+		return err
+	}
+	var trackType, defaultDuration uint64
+	var codecID, language, bcp47 string
+	var video, audio *element
+	for i, e := range elements {
+		switch e.id {
+		case idTrackType:
+			trackType, err = r.readUint(e)
+		case idCodecID:
+			codecID, err = r.readString(e)
+		case idLanguage:
+			language, err = r.readString(e)
+		case idLanguageBCP47:
+			bcp47, err = r.readString(e)
+		case idDefaultDuration:
+			defaultDuration, err = r.readUint(e)
+		case idVideo:
+			video = &elements[i]
+		case idAudio:
+			audio = &elements[i]
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case trackType == trackTypeVideo && video != nil:
+		track, err := r.readVideo(*video)
+		if err != nil {
+			return err
+		}
+		track.Index, track.Codec = index, webmCodec(codecID)
+		if defaultDuration > 0 {
+			track.FrameRate = math.Round(1e9/float64(defaultDuration)*1000) / 1000
+		}
+		info.VideoTracks = append(info.VideoTracks, track)
+	case trackType == trackTypeAudio:
+		track := mediamachine.AudioTrack{Index: index, Codec: webmCodec(codecID), Channels: 1, Language: bcp47}
+		if track.Language == "" && language != "und" {
+			track.Language = language
+			if language == "" {
+				// the default Language of Matroska tracks is English
+				track.Language = "eng"
+			}
+		}
+		if audio != nil {
+			if err := r.readAudio(*audio, &track); err != nil {
+				return err
+			}
+		}
+		info.AudioTracks = append(info.AudioTracks, track)
+	}
+	return nil
+}
+
+func (r reader) readVideo(video element) (mediamachine.VideoTrack, error) {
+	var track mediamachine.VideoTrack
+	elements, err := r.subElements(video)
+	if err != nil {
+		return track, err
+	}
+	for _, e := range elements {
+		var v uint64
+		switch e.id {
+		case idPixelWidth:
+			v, err = r.readUint(e)
+			track.Width = uint(v)
+		case idPixelHeight:
+			v, err = r.readUint(e)
+			track.Height = uint(v)
+		case idColour:
+			err = r.readColour(e, &track)
+		}
+		if err != nil {
+			return track, err
+		}
+	}
+	return track, nil
+}
+
+func (r reader) readColour(colour element, track *mediamachine.VideoTrack) error {
+	elements, err := r.subElements(colour)
+	if err != nil {
+		return err
+	}
+	for _, e := range elements {
+		var v uint64
+		switch e.id {
+		case idBitsPerChannel:
+			v, err = r.readUint(e)
+			track.BitDepth = uint(v)
+		case idPrimaries:
+			v, err = r.readUint(e)
+			track.ColorPrimaries = colorPrimaries[v]
+		case idTransferCharacteristics:
+			v, err = r.readUint(e)
+			track.TransferCharacteristics = transferCharacteristics[v]
+			track.HDR = hdrFormat(v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r reader) readAudio(audio element, track *mediamachine.AudioTrack) error {
+	elements, err := r.subElements(audio)
+	if err != nil {
+		return err
+	}
+	for _, e := range elements {
+		switch e.id {
+		case idSamplingFrequency:
+			var rate float64
+			rate, err = r.readFloat(e)
+			track.SampleRate = uint(rate)
+		case idChannels:
+			var channels uint64
+			channels, err = r.readUint(e)
+			track.Channels = uint(channels)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func webmCodec(codecID string) string {
+	for _, c := range webmCodecs {
+		if strings.HasPrefix(codecID, c.prefix) {
+			return c.name
+		}
+	}
+	return codecID
+}
@@ -0,0 +1,181 @@
+/*
+Package watermark renders MediaMachine watermarks locally, so that their placement can be reviewed before submitting jobs.
+
+The preview follows the layout rules of the mediamachine package: positions, offsets, margins, tiling, sizes,
+opacity and colors. Text is drawn with a simple built-in bitmap font scaled to FontSize, so glyph shapes and
+exact text widths differ from the output of the MediaMachine API, and characters outside of ASCII are drawn as boxes.
+*/
+package watermark
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"unicode/utf8"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/stackrock/mediamachinego/colors"
+	"github.com/stackrock/mediamachinego/mediamachine"
+)
+
+// placeholderColor is used to draw image watermarks, which are not fetched by the preview
+var placeholderColor = color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}
+
+/*
+Preview renders wm onto a copy of frame scaled to outWidth, keeping the aspect ratio of frame.
+If outWidth is 0, the frame keeps its size. An empty frame has nothing to draw on and is returned unchanged.
+
+Image watermarks are not downloaded, they are drawn as grey placeholders of their computed size, and are
+skipped when their size cannot be computed locally. Templated text is rendered with sample values.
+*/
+func Preview(frame image.Image, wm mediamachine.Watermark, outWidth uint) image.Image {
+	src := frame.Bounds()
+	if src.Empty() {
+		return frame
+	}
+	if outWidth == 0 {
+		outWidth = uint(src.Dx())
+	}
+	outHeight := uint(float64(src.Dy())*float64(outWidth)/float64(src.Dx()) + 0.5)
+	out := mediamachine.Size{Width: outWidth, Height: outHeight}
+
+	dst := image.NewRGBA(image.Rect(0, 0, int(outWidth), int(outHeight)))
+	xdraw.ApproxBiLinear.Scale(dst, dst.Bounds(), frame, src, draw.Src, nil)
+
+	for _, rect := range mediamachine.WatermarkTiles(wm, out) {
+		switch wm := wm.(type) {
+		case mediamachine.WatermarkText:
+			drawText(dst, rect, wm)
+		case mediamachine.WatermarkImageURL:
+			drawPlaceholder(dst, rect, wm.Opacity)
+		case mediamachine.WatermarkImageNamed:
+			drawPlaceholder(dst, rect, wm.Opacity)
+		}
+	}
+	return dst
+}
+
+func drawPlaceholder(dst draw.Image, rect image.Rectangle, opacity float32) {
+	opacity = effectiveOpacity(opacity)
+	fill := withOpacity(placeholderColor, opacity*0.5)
+	draw.Draw(dst, rect, image.NewUniform(fill), image.Point{}, draw.Over)
+
+	border := image.NewUniform(withOpacity(placeholderColor, opacity))
+	for _, edge := range []image.Rectangle{
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+2),
+		image.Rect(rect.Min.X, rect.Max.Y-2, rect.Max.X, rect.Max.Y),
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+2, rect.Max.Y),
+		image.Rect(rect.Max.X-2, rect.Min.Y, rect.Max.X, rect.Max.Y),
+	} {
+		draw.Draw(dst, edge, border, image.Point{}, draw.Over)
+	}
+}
+
+func drawText(dst draw.Image, rect image.Rectangle, wm mediamachine.WatermarkText) {
+	var inset int
+	if wm.Stroke != nil {
+		inset += int(wm.Stroke.Width)
+	}
+	if wm.Background != nil {
+		inset += int(wm.Background.Padding)
+		draw.Draw(dst, rect, image.NewUniform(parseColor(wm.Background.Color, colors.Black, wm.Opacity)), image.Point{}, draw.Over)
+	}
+	inner := rect.Inset(inset)
+	if inner.Empty() {
+		return
+	}
+
+	mask := image.NewAlpha(image.Rect(0, 0, inner.Dx(), inner.Dy()))
+	xdraw.ApproxBiLinear.Scale(mask, mask.Bounds(), textMask(wm), textMaskBounds(wm), draw.Src, nil)
+
+	if wm.Shadow != nil {
+		shadow := inner.Add(image.Pt(wm.Shadow.OffsetX, wm.Shadow.OffsetY))
+		draw.DrawMask(dst, shadow, image.NewUniform(parseColor(wm.Shadow.Color, colors.Black, wm.Opacity)),
+			image.Point{}, mask, image.Point{}, draw.Over)
+	}
+	if wm.Stroke != nil {
+		stroke := image.NewUniform(parseColor(wm.Stroke.Color, colors.Black, wm.Opacity))
+		sw := int(wm.Stroke.Width)
+		for dy := -sw; dy <= sw; dy++ {
+			for dx := -sw; dx <= sw; dx++ {
+				if dx*dx+dy*dy > sw*sw || (dx == 0 && dy == 0) {
+					continue
+				}
+				draw.DrawMask(dst, inner.Add(image.Pt(dx, dy)), stroke, image.Point{}, mask, image.Point{}, draw.Over)
+			}
+		}
+	}
+	fill := image.NewUniform(parseColor(wm.FontColor, colors.Black, wm.Opacity))
+	draw.DrawMask(dst, inner, fill, image.Point{}, mask, image.Point{}, draw.Over)
+}
+
+func textMaskBounds(wm mediamachine.WatermarkText) image.Rectangle {
+	face := basicfont.Face7x13
+	lines := wm.Lines()
+	longest := 0
+	for _, line := range lines {
+		if n := utf8.RuneCountInString(line); n > longest {
+			longest = n
+		}
+	}
+	return image.Rect(0, 0, longest*face.Advance, len(lines)*face.Height)
+}
+
+// textMask draws the lines of the watermark with the bitmap font at its native size
+func textMask(wm mediamachine.WatermarkText) *image.Alpha {
+	face := basicfont.Face7x13
+	bounds := textMaskBounds(wm)
+	mask := image.NewAlpha(bounds)
+
+	align := wm.Align
+	if align == "" && wm.IsRTL() {
+		align = mediamachine.AlignRight
+	}
+	for i, line := range wm.Lines() {
+		width := utf8.RuneCountInString(line) * face.Advance
+		x := 0
+		switch align {
+		case mediamachine.AlignCenter:
+			x = (bounds.Dx() - width) / 2
+		case mediamachine.AlignRight:
+			x = bounds.Dx() - width
+		}
+		d := font.Drawer{
+			Dst:  mask,
+			Src:  image.Opaque,
+			Face: face,
+			Dot:  fixed.P(x, i*face.Height+face.Ascent),
+		}
+		d.DrawString(line)
+	}
+	return mask
+}
+
+// parseColor resolves a watermark color, falling back to def when it is not set or invalid
+func parseColor(c, def string, opacity float32) color.NRGBA {
+	rgba, err := colors.Parse(c)
+	if err != nil {
+		rgba, _ = colors.Parse(def)
+	}
+	return withOpacity(rgba, effectiveOpacity(opacity))
+}
+
+// effectiveOpacity clamps opacity between 0 (transparent) and 1 (opaque)
+func effectiveOpacity(opacity float32) float32 {
+	if opacity < 0 {
+		return 0
+	}
+	if opacity > 1 {
+		return 1
+	}
+	return opacity
+}
+
+func withOpacity(c color.NRGBA, opacity float32) color.NRGBA {
+	c.A = uint8(float32(c.A)*opacity + 0.5)
+	return c
+}
@@ -0,0 +1,85 @@
+package watermark_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/stackrock/mediamachinego/colors"
+	"github.com/stackrock/mediamachinego/mediamachine"
+	"github.com/stackrock/mediamachinego/watermark"
+)
+
+var _ = Describe("Preview", func() {
+	frame := image.NewRGBA(image.Rect(0, 0, 1920, 1080))
+	draw.Draw(frame, frame.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	It("scales the frame to the output width", func() {
+		out := watermark.Preview(frame, nil, 640)
+		Expect(out.Bounds()).To(Equal(image.Rect(0, 0, 640, 360)))
+	})
+
+	It("draws text in its corner with the font color", func() {
+		out := watermark.Preview(frame, mediamachine.WatermarkText{
+			Text:       "MediaMachine",
+			FontSize:   40,
+			FontColor:  colors.Red,
+			Opacity:    1,
+			Position:   mediamachine.PositionBottomRight,
+			Background: &mediamachine.TextBackground{Color: colors.Blue},
+		}, 1280)
+
+		rect, ok := mediamachine.WatermarkRect(mediamachine.WatermarkText{Text: "MediaMachine", FontSize: 40}, mediamachine.Size{Width: 1280, Height: 720})
+		Expect(ok).To(BeTrue())
+
+		var red, blue int
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				r, _, b, _ := out.At(x, y).RGBA()
+				if r > 0xf000 && b < 0x1000 {
+					red++
+				}
+				if b > 0xf000 && r < 0x1000 {
+					blue++
+				}
+			}
+		}
+		Expect(red).To(BeNumerically(">", 0))
+		Expect(blue).To(BeNumerically(">", 0))
+
+		// the top left corner of the frame is untouched
+		Expect(out.At(0, 0)).To(Equal(color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}))
+	})
+
+	It("blends image placeholders with the watermark opacity", func() {
+		out := watermark.Preview(frame, mediamachine.WatermarkImageNamed{
+			ImageName: "logo",
+			Width:     100,
+			Height:    100,
+			Opacity:   0.5,
+			Position:  mediamachine.PositionTopLeft,
+		}, 0)
+		Expect(out.At(5, 5)).To(Equal(color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}))
+		r, _, _, _ := out.At(60, 60).RGBA()
+		Expect(r).To(BeNumerically("<", 0xffff))
+		Expect(r).To(BeNumerically(">", 0x8080))
+	})
+
+	It("does not draw transparent watermarks", func() {
+		out := watermark.Preview(frame, mediamachine.WatermarkImageNamed{
+			ImageName: "logo",
+			Width:     100,
+			Height:    100,
+			Position:  mediamachine.PositionTopLeft,
+		}, 0)
+		Expect(out.At(60, 60)).To(Equal(color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}))
+	})
+
+	It("returns empty frames unchanged", func() {
+		empty := image.NewRGBA(image.Rect(0, 0, 0, 0))
+		Expect(watermark.Preview(empty, mediamachine.WatermarkText{Text: "hello"}, 640)).To(BeIdenticalTo(empty))
+	})
+})